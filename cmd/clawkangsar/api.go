@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"clawkangsar/internal/core"
+	"clawkangsar/internal/health"
+	"clawkangsar/internal/tools"
+)
+
+// adminAPI implements the /api endpoints on top of the running agent.
+type adminAPI struct {
+	agent    *core.Agent
+	sessions *core.SessionStore
+	browser  *tools.Browser
+	reloader *reloader
+}
+
+func (a *adminAPI) Sessions() any {
+	return a.sessions.Sessions()
+}
+
+func (a *adminAPI) Session(key string) (any, error) {
+	session, ok := a.sessions.Session(key)
+	if !ok {
+		return nil, fmt.Errorf("session %q: %w", key, health.ErrNotFound)
+	}
+	return session, nil
+}
+
+func (a *adminAPI) DeleteSession(ctx context.Context, key string) (int, error) {
+	if _, ok := a.sessions.Session(key); !ok {
+		return 0, fmt.Errorf("session %q: %w", key, health.ErrNotFound)
+	}
+	return a.agent.DeleteSession(ctx, key)
+}
+
+func (a *adminAPI) SendMessage(ctx context.Context, req health.MessageRequest) (string, error) {
+	return a.agent.Process(ctx, core.Message{
+		Channel: req.Channel,
+		UserID:  req.UserID,
+		ChatID:  req.ChatID,
+		Text:    req.Text,
+	})
+}
+
+func (a *adminAPI) Usage(day string) any {
+	return a.agent.UsageSummary(day)
+}
+
+func (a *adminAPI) Tools() any {
+	return a.agent.ToolDefinitions()
+}
+
+func (a *adminAPI) CallTool(ctx context.Context, name string, arguments map[string]any) (string, error) {
+	return a.agent.CallTool(ctx, name, arguments)
+}
+
+func (a *adminAPI) Reload(context.Context) (any, error) {
+	result, err := a.reloader.Reload()
+	a.agent.RecordAPIAction("reload", nil, err)
+	return result, err
+}
+
+func (a *adminAPI) KillBrowser() bool {
+	killed := a.browser.Kill("api")
+	a.agent.RecordAPIAction("kill_browser", map[string]any{"killed": killed}, nil)
+	return killed
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -20,7 +21,9 @@ import (
 	"clawkangsar/internal/gateway/whatsapp"
 	"clawkangsar/internal/health"
 	"clawkangsar/internal/llm"
+	"clawkangsar/internal/monitor"
 	"clawkangsar/internal/setup"
+	"clawkangsar/internal/storage"
 	"clawkangsar/internal/tools"
 	"clawkangsar/internal/version"
 )
@@ -28,6 +31,9 @@ import (
 type runner struct {
 	name  string
 	start func(ctx context.Context) error
+	// notifier sends messages that are not replies, such as scheduled job
+	// output and alerts.
+	notifier core.Notifier
 }
 
 type gatewayRuntime struct {
@@ -45,6 +51,8 @@ type statusTracker struct {
 	gateways map[string]*gatewayRuntime
 	agent    *core.Agent
 	browser  *tools.Browser
+	watcher  *monitor.ServiceWatcher
+	logWatch *monitor.LogWatcher
 }
 
 func main() {
@@ -78,17 +86,25 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger := newLogger(cfg.LogLevel)
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(parseLogLevel(cfg.LogLevel))
+	logger := newLogger(logLevel)
 	logger.Info("starting service", "app", version.AppName, "version", version.Version)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
-	sessionStore, err := core.NewSessionStore(cfg.Storage.SessionDir)
+	sessionBackend, err := openSessionBackend(cfg.Storage, logger)
 	if err != nil {
-		logger.Error("failed to initialize session store", "path", cfg.Storage.SessionDir, "error", err)
+		logger.Error("failed to open session storage", "backend", cfg.Storage.SessionBackend, "error", err)
 		os.Exit(1)
 	}
+	sessionStore, err := core.NewSessionStore(sessionBackend)
+	if err != nil {
+		logger.Error("failed to initialize session store", "backend", cfg.Storage.SessionBackend, "error", err)
+		os.Exit(1)
+	}
+	defer sessionStore.Close()
 
 	browser := tools.NewBrowser(logger.With("component", "browser"), time.Duration(cfg.Browser.IdleTimeoutSeconds)*time.Second)
 	defer browser.Close()
@@ -99,41 +115,142 @@ func main() {
 		cfg.Tools.WebFetchMaxChars,
 	)
 	serverControl := tools.NewServerControl(logger.With("component", "server_tools"), tools.ServerControlOptions{
-		TimeoutSeconds:         cfg.Tools.CommandTimeoutSeconds,
-		DefaultLogLines:        cfg.Tools.DefaultLogLines,
-		MaxLogLines:            cfg.Tools.MaxLogLines,
-		ShellEnabled:           cfg.Tools.ShellEnabled,
-		ShellCommands:          cfg.Tools.ShellCommands,
-		SystemctlEnabled:       cfg.Tools.SystemctlEnabled,
-		SystemctlAllowServices: cfg.Tools.SystemctlAllowServices,
-		DockerEnabled:          cfg.Tools.DockerEnabled,
-		DockerAllowContainers:  cfg.Tools.DockerAllowContainers,
-		JournalEnabled:         cfg.Tools.JournalEnabled,
-		JournalAllowUnits:      cfg.Tools.JournalAllowUnits,
+		TimeoutSeconds:           cfg.Tools.CommandTimeoutSeconds,
+		DefaultLogLines:          cfg.Tools.DefaultLogLines,
+		MaxLogLines:              cfg.Tools.MaxLogLines,
+		ShellEnabled:             cfg.Tools.ShellEnabled,
+		ShellCommands:            cfg.Tools.ShellCommands,
+		SystemctlEnabled:         cfg.Tools.SystemctlEnabled,
+		SystemctlAllowServices:   cfg.Tools.SystemctlAllowServices,
+		SystemctlConfirmActions:  cfg.Tools.SystemctlConfirmActions,
+		SystemctlConfirmServices: cfg.Tools.SystemctlConfirmServices,
+		DockerEnabled:            cfg.Tools.DockerEnabled,
+		DockerAllowContainers:    cfg.Tools.DockerAllowContainers,
+		JournalEnabled:           cfg.Tools.JournalEnabled,
+		JournalAllowUnits:        cfg.Tools.JournalAllowUnits,
 	})
 
 	var provider core.ChatProvider
 	if cfg.LLM.Enabled {
-		switch strings.ToLower(strings.TrimSpace(cfg.LLM.Provider)) {
-		case "openai_compat", "openai-compatible", "openai":
-			provider, err = llm.NewOpenAICompatProvider(cfg.LLM)
-			if err != nil {
-				logger.Error("failed to initialize llm provider", "provider", cfg.LLM.Provider, "error", err)
-				os.Exit(1)
-			}
-		case "codex_oauth", "openai_oauth", "codex":
-			provider, err = llm.NewCodexOAuthProvider(cfg.LLM)
-			if err != nil {
-				logger.Error("failed to initialize llm provider", "provider", cfg.LLM.Provider, "error", err)
-				os.Exit(1)
-			}
-		default:
-			logger.Error("unsupported llm provider", "provider", cfg.LLM.Provider)
+		chain, err := llm.NewFallbackProvider(cfg.LLM, logger.With("component", "llm"))
+		if err != nil {
+			logger.Error("failed to initialize llm provider", "provider", cfg.LLM.Provider, "error", err)
+			os.Exit(1)
+		}
+		if err := chain.CheckModel(ctx); err != nil {
+			// A local model server may still be booting or loading its
+			// model. Gateways and non-LLM commands keep working meanwhile.
+			logger.Error("llm model is not available yet; starting anyway", "provider", cfg.LLM.Provider, "model", cfg.LLM.Model, "error", err)
+			go waitForModel(ctx, chain, logger.With("component", "llm"))
+		}
+		provider = chain
+	}
+
+	registry := core.NewToolRegistry()
+	if err := tools.RegisterBuiltins(registry, browser, webFetcher, serverControl); err != nil {
+		logger.Error("failed to register tools", "error", err)
+		os.Exit(1)
+	}
+	metricsCollector := monitor.NewMetricsCollector(cfg.SystemMetrics.Mounts)
+	if cfg.SystemMetrics.ToolEnabled {
+		if err := registry.Register(monitor.NewMetricsTool(metricsCollector)); err != nil {
+			logger.Error("failed to register tools", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	sharing, err := core.ParseMemorySharing(cfg.Memory.Sharing)
+	if err != nil {
+		logger.Error("invalid memory config", "error", err)
+		os.Exit(1)
+	}
+
+	identities, err := core.NewIdentityStore(
+		cfg.Identities.LinksPath,
+		configuredPeople(cfg.Identities.People),
+		time.Duration(cfg.Identities.LinkCodeMinutes)*time.Minute,
+	)
+	if err != nil {
+		logger.Error("failed to load identities", "error", err)
+		os.Exit(1)
+	}
+
+	access, err := core.NewAccessPolicy(cfg.Access.DefaultRole, cfg.Access.Users, cfg.Access.ToolRoles, cfg.Access.CommandRoles)
+	if err != nil {
+		logger.Error("invalid access config", "error", err)
+		os.Exit(1)
+	}
+
+	var auditLog core.AuditLog
+	if cfg.Audit.Enabled {
+		auditFile, err := core.OpenAuditFile(cfg.Audit.Path, cfg.Audit.MaxBytes, cfg.Audit.MaxFiles)
+		if err != nil {
+			logger.Error("failed to open audit log", "path", cfg.Audit.Path, "error", err)
+			os.Exit(1)
+		}
+		defer auditFile.Close()
+		auditLog = auditFile
+	}
+
+	var schedules *core.ScheduleStore
+	if cfg.Scheduler.Enabled {
+		schedules, err = core.NewScheduleStore(cfg.Scheduler.Path, cfg.Scheduler.MaxJobsPerUser)
+		if err != nil {
+			logger.Error("failed to load schedules", "path", cfg.Scheduler.Path, "error", err)
+			os.Exit(1)
+		}
+	}
+
+	var usage *core.UsageTracker
+	if cfg.Usage.Enabled {
+		usage, err = core.NewUsageTracker(
+			cfg.Usage.Path,
+			configuredPrices(cfg.Usage.Prices),
+			core.UsageBudget{
+				DailyTokens:     cfg.Usage.DailyTokenBudget,
+				DailyCost:       cfg.Usage.DailyCostBudget,
+				UserDailyTokens: cfg.Usage.UserDailyTokenBudget,
+				UserDailyCost:   cfg.Usage.UserDailyCostBudget,
+			},
+			cfg.Usage.RetainDays,
+		)
+		if err != nil {
+			logger.Error("failed to load usage", "path", cfg.Usage.Path, "error", err)
 			os.Exit(1)
 		}
+		defer func() {
+			if err := usage.Close(); err != nil {
+				logger.Warn("failed to save usage", "path", cfg.Usage.Path, "error", err)
+			}
+		}()
 	}
 
-	agent := core.NewAgent(cfg.SystemPrompt, browser, webFetcher, serverControl, provider, sessionStore)
+	agent := core.NewAgent(core.AgentOptions{
+		SystemPrompt:    cfg.SystemPrompt,
+		Tools:           registry,
+		LLM:             provider,
+		Sessions:        sessionStore,
+		HistoryMessages: cfg.LLM.HistoryMessages,
+		ContextTokens:   cfg.LLM.ContextTokens,
+		SummarizeAfter:  cfg.LLM.SummarizeAfter,
+		MemorySharing:   sharing,
+		Identities:      identities,
+		Access:          access,
+		ConfirmTimeout:  time.Duration(cfg.Tools.ConfirmTimeoutSeconds) * time.Second,
+		Audit:           auditLog,
+		Usage:           usage,
+		UserRateLimit: core.RateLimit{
+			PerMinute: cfg.Limits.UserMessagesPerMinute,
+			Burst:     cfg.Limits.UserBurst,
+		},
+		ChannelRateLimit: core.RateLimit{
+			PerMinute: cfg.Limits.ChannelMessagesPerMinute,
+			Burst:     cfg.Limits.ChannelBurst,
+		},
+		MaxConcurrentLLM: cfg.Limits.MaxConcurrentLLM,
+		Schedules:        schedules,
+		Logger:           logger.With("component", "agent"),
+	})
 
 	runners, err := buildRunners(cfg, agent, logger)
 	if err != nil {
@@ -144,7 +261,116 @@ func main() {
 		logger.Warn("no gateway enabled; set whatsapp.enabled or telegram.enabled in config.json")
 	}
 
-	tracker := newStatusTracker(version.AppName, version.Version, runners, agent, browser)
+	notifications := core.NewNotificationRouter(
+		identities,
+		cfg.Notifications.Preferred,
+		cfg.Notifications.ChannelOrder,
+		logger.With("component", "notifications"),
+	)
+	for _, r := range runners {
+		if r.notifier != nil {
+			notifications.Register(r.name, r.notifier)
+		}
+	}
+
+	var watcher *monitor.ServiceWatcher
+	if cfg.Watch.Enabled {
+		services := cfg.Watch.Services
+		if len(services) == 0 {
+			services = serverControl.AllowedServices()
+		}
+		containers := cfg.Watch.Containers
+		if len(containers) == 0 {
+			containers = serverControl.AllowedContainers()
+		}
+		watcher, err = monitor.NewServiceWatcher(monitor.ServiceWatcherOptions{
+			Control:           serverControl,
+			Services:          services,
+			Containers:        containers,
+			Chats:             cfg.Watch.Chats,
+			Deliver:           notifications.Send,
+			Interval:          time.Duration(cfg.Watch.IntervalSeconds) * time.Second,
+			LogLines:          cfg.Watch.LogLines,
+			RestartLoopCount:  cfg.Watch.RestartLoopCount,
+			RestartLoopWindow: time.Duration(cfg.Watch.RestartLoopMinutes) * time.Minute,
+			Logger:            logger.With("component", "watch"),
+		})
+		if err != nil {
+			logger.Error("invalid watch config", "error", err)
+			os.Exit(1)
+		}
+		if watcher.Empty() {
+			logger.Warn("watch enabled but no services or containers are allow-listed")
+		}
+		if len(cfg.Watch.Chats) == 0 {
+			logger.Warn("watch enabled but watch.chats is empty; alerts are only logged")
+		}
+	}
+
+	var metricsAlerts *monitor.MetricsAlerts
+	if cfg.SystemMetrics.AlertsEnabled {
+		metricsAlerts, err = monitor.NewMetricsAlerts(monitor.MetricsAlertOptions{
+			Collector: metricsCollector,
+			Thresholds: monitor.Thresholds{
+				LoadPerCPU:        cfg.SystemMetrics.LoadPerCPU,
+				TemperatureC:      cfg.SystemMetrics.TemperatureC,
+				MemoryPercent:     cfg.SystemMetrics.MemoryPercent,
+				DiskPercent:       cfg.SystemMetrics.DiskPercent,
+				HysteresisPercent: cfg.SystemMetrics.HysteresisPercent,
+				Throttling:        cfg.SystemMetrics.AlertThrottling,
+			},
+			Chats:    cfg.SystemMetrics.Chats,
+			Deliver:  notifications.Send,
+			Interval: time.Duration(cfg.SystemMetrics.IntervalSeconds) * time.Second,
+			Logger:   logger.With("component", "system_metrics"),
+		})
+		if err != nil {
+			logger.Error("invalid system_metrics config", "error", err)
+			os.Exit(1)
+		}
+		if len(cfg.SystemMetrics.Chats) == 0 {
+			logger.Warn("system_metrics alerts enabled but system_metrics.chats is empty; alerts are only logged")
+		}
+	}
+
+	var logWatcher *monitor.LogWatcher
+	if cfg.LogWatch.Enabled {
+		units := cfg.LogWatch.Units
+		if len(units) == 0 {
+			units = serverControl.AllowedUnits()
+		}
+		containers := cfg.LogWatch.Containers
+		if len(containers) == 0 {
+			containers = serverControl.AllowedContainers()
+		}
+		logWatcher, err = monitor.NewLogWatcher(monitor.LogWatcherOptions{
+			Control:      serverControl,
+			Units:        units,
+			Containers:   containers,
+			Chats:        cfg.LogWatch.Chats,
+			Rules:        configuredLogRules(cfg.LogWatch.Rules),
+			ContextLines: cfg.LogWatch.ContextLines,
+			Deliver:      notifications.Send,
+			Logger:       logger.With("component", "log_watch"),
+		})
+		if err != nil {
+			logger.Error("invalid log_watch config", "error", err)
+			os.Exit(1)
+		}
+		if logWatcher.Empty() {
+			logger.Warn("log_watch enabled but there are no allow-listed units or containers, or no rules")
+		}
+	}
+
+	configReloader := &reloader{
+		path:     *configPath,
+		current:  cfg,
+		agent:    agent,
+		logLevel: logLevel,
+		logger:   logger.With("component", "config"),
+	}
+
+	tracker := newStatusTracker(version.AppName, version.Version, runners, agent, browser, watcher, logWatcher)
 
 	var wg sync.WaitGroup
 
@@ -156,6 +382,64 @@ func main() {
 			tracker.ready,
 			logger.With("component", "health"),
 		)
+		if cfg.Health.TLSCertFile != "" || cfg.Health.TLSKeyFile != "" {
+			if err := healthServer.UseTLS(cfg.Health.TLSCertFile, cfg.Health.TLSKeyFile, cfg.Health.ClientCAFile); err != nil {
+				logger.Error("invalid health TLS config", "error", err)
+				os.Exit(1)
+			}
+		}
+		// The audit trail holds tool arguments and user IDs, so it needs the
+		// API token or a client certificate even when the API is disabled.
+		if apiToken := cfg.API.ResolvedToken(); apiToken != "" || cfg.Health.ClientCAFile != "" {
+			healthServer.Handle("/audit", health.RequireAuth(apiToken, health.AuditHandler(func(limit int) (any, error) {
+				return agent.RecentAudit(limit)
+			})))
+		} else {
+			logger.Info("audit endpoint disabled; set api.token, api.token_env or health.client_ca_file")
+		}
+		if cfg.Health.MetricsEnabled {
+			healthServer.Handle("/metrics", health.MetricsHandler(tracker.writeMetrics))
+		}
+		if token := cfg.Notifications.ResolvedToken(); token != "" {
+			healthServer.Handle("/notify", health.NotifyHandler(token, func(ctx context.Context, req health.NotifyRequest) (string, error) {
+				if strings.TrimSpace(req.User) != "" {
+					return notifications.NotifyUser(ctx, req.User, req.Text)
+				}
+				return req.Channel, notifications.Send(ctx, req.Channel, req.ChatID, req.Text)
+			}))
+		} else {
+			logger.Info("notify endpoint disabled; set notifications.token or notifications.token_env")
+		}
+		admin := &adminAPI{
+			agent:    agent,
+			sessions: sessionStore,
+			browser:  browser,
+			reloader: configReloader,
+		}
+		if cfg.API.Enabled {
+			token := cfg.API.ResolvedToken()
+			if token == "" && cfg.Health.ClientCAFile == "" {
+				logger.Warn("api enabled but no api.token, api.token_env or health.client_ca_file is set; every request is rejected")
+			}
+			healthServer.Handle("/api/", health.APIHandler(token, admin))
+		}
+		if cfg.Dashboard.Enabled {
+			password := cfg.Dashboard.ResolvedPassword()
+			if password == "" {
+				logger.Warn("dashboard enabled but no dashboard.password or dashboard.password_env is set; login is refused")
+			}
+			healthServer.Handle("/dashboard/", health.DashboardHandler(health.DashboardOptions{
+				Username:   cfg.Dashboard.Username,
+				Password:   password,
+				SessionTTL: time.Duration(cfg.Dashboard.SessionHours) * time.Hour,
+				Snapshot:   tracker.snapshot,
+				Audit: func(limit int) (any, error) {
+					return agent.RecentAudit(limit)
+				},
+				Admin:  admin,
+				Logger: logger.With("component", "dashboard"),
+			}))
+		}
 
 		wg.Add(1)
 		go func() {
@@ -166,6 +450,70 @@ func main() {
 		}()
 	}
 
+	retention := core.RetentionPolicy{
+		MaxAge:                time.Duration(cfg.Storage.MaxSessionAgeDays) * 24 * time.Hour,
+		MaxMessagesPerSession: cfg.Storage.MaxSessionMessages,
+		MaxTotalBytes:         cfg.Storage.MaxTotalBytes,
+	}
+	if retention.Enabled() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agent.RunRetention(ctx, retention, time.Duration(cfg.Storage.PruneIntervalMinutes)*time.Minute)
+		}()
+	}
+
+	if schedules != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			agent.RunSchedules(ctx, notifications.Send)
+		}()
+	}
+
+	if metricsAlerts != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			metricsAlerts.Run(ctx)
+		}()
+	}
+
+	if logWatcher != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logWatcher.Run(ctx)
+		}()
+	}
+
+	if watcher != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watcher.Run(ctx)
+		}()
+	}
+
+	// SIGHUP reloads the config file like POST /api/reload.
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	defer signal.Stop(reloadSignals)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reloadSignals:
+				if _, err := configReloader.Reload(); err != nil {
+					logger.Error("config reload failed", "path", *configPath, "error", err)
+				}
+			}
+		}
+	}()
+
 	for _, gatewayRunner := range runners {
 		gatewayRunner := gatewayRunner
 		wg.Add(1)
@@ -233,6 +581,89 @@ func runSetupCommand(args []string) error {
 	}, os.Stdin, os.Stdout, os.Stderr)
 }
 
+// waitForModel repeats the startup model check with backoff until it passes
+// or ctx ends.
+func waitForModel(ctx context.Context, chain *llm.FallbackProvider, logger *slog.Logger) {
+	delay := 5 * time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		err := chain.CheckModel(ctx)
+		if err == nil {
+			logger.Info("llm model is available")
+			return
+		}
+		delay = min(delay*2, time.Minute)
+		logger.Warn("llm model is still not available", "error", err, "retry_in", delay)
+	}
+}
+
+func openSessionBackend(cfg config.StorageConfig, logger *slog.Logger) (core.SessionBackend, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.SessionBackend)) {
+	case "json":
+		return core.NewJSONSessionBackend(cfg.SessionDir)
+	case "sqlite":
+		backend, err := storage.OpenSQLiteSessions(cfg.SessionDB)
+		if err != nil {
+			return nil, err
+		}
+		migrated, err := storage.MigrateJSONSessions(cfg.SessionDir, backend)
+		if err != nil {
+			_ = backend.Close()
+			return nil, fmt.Errorf("migrate json sessions: %w", err)
+		}
+		if migrated > 0 {
+			logger.Info("migrated json sessions to sqlite", "sessions", migrated, "path", cfg.SessionDB)
+		}
+		return backend, nil
+	case "memory":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported session backend %q", cfg.SessionBackend)
+	}
+}
+
+func configuredPeople(people []config.PersonConfig) []core.Person {
+	out := make([]core.Person, 0, len(people))
+	for _, person := range people {
+		accounts := make([]string, 0, len(person.Telegram)+len(person.WhatsApp))
+		for _, id := range person.Telegram {
+			accounts = append(accounts, "telegram:"+strconv.FormatInt(id, 10))
+		}
+		for _, jid := range person.WhatsApp {
+			accounts = append(accounts, "whatsapp:"+jid)
+		}
+		out = append(out, core.Person{ID: person.ID, Accounts: accounts})
+	}
+	return out
+}
+
+func configuredPrices(prices map[string]config.ModelPrice) map[string]core.ModelPrice {
+	out := make(map[string]core.ModelPrice, len(prices))
+	for model, price := range prices {
+		out[model] = core.ModelPrice{Input: price.InputPerMillion, Output: price.OutputPerMillion}
+	}
+	return out
+}
+
+func configuredLogRules(rules []config.LogRuleConfig) []monitor.LogRule {
+	out := make([]monitor.LogRule, 0, len(rules))
+	for _, rule := range rules {
+		out = append(out, monitor.LogRule{
+			Name:     rule.Name,
+			Pattern:  rule.Pattern,
+			Sources:  rule.Sources,
+			Chats:    rule.Chats,
+			Cooldown: time.Duration(rule.CooldownSeconds) * time.Second,
+		})
+	}
+	return out
+}
+
 func buildRunners(cfg config.Config, processor core.Processor, logger *slog.Logger) ([]runner, error) {
 	runners := make([]runner, 0, 2)
 
@@ -242,8 +673,9 @@ func buildRunners(cfg config.Config, processor core.Processor, logger *slog.Logg
 			return nil, err
 		}
 		runners = append(runners, runner{
-			name:  "telegram",
-			start: tgGateway.Start,
+			name:     "telegram",
+			start:    tgGateway.Start,
+			notifier: tgGateway,
 		})
 	}
 
@@ -253,15 +685,16 @@ func buildRunners(cfg config.Config, processor core.Processor, logger *slog.Logg
 			return nil, err
 		}
 		runners = append(runners, runner{
-			name:  "whatsapp",
-			start: waGateway.Start,
+			name:     "whatsapp",
+			start:    waGateway.Start,
+			notifier: waGateway,
 		})
 	}
 
 	return runners, nil
 }
 
-func newStatusTracker(app string, appVersion string, runners []runner, agent *core.Agent, browser *tools.Browser) *statusTracker {
+func newStatusTracker(app string, appVersion string, runners []runner, agent *core.Agent, browser *tools.Browser, watcher *monitor.ServiceWatcher, logWatch *monitor.LogWatcher) *statusTracker {
 	gateways := make(map[string]*gatewayRuntime, len(runners))
 	for _, r := range runners {
 		gateways[r.name] = &gatewayRuntime{
@@ -278,6 +711,8 @@ func newStatusTracker(app string, appVersion string, runners []runner, agent *co
 		gateways: gateways,
 		agent:    agent,
 		browser:  browser,
+		watcher:  watcher,
+		logWatch: logWatch,
 	}
 }
 
@@ -360,22 +795,31 @@ func (s *statusTracker) snapshot() map[string]any {
 	if s.browser != nil {
 		payload["browser"] = s.browser.Stats()
 	}
+	if s.watcher != nil {
+		payload["watch"] = s.watcher.Snapshot()
+	}
+	if s.logWatch != nil {
+		payload["log_watch"] = s.logWatch.Snapshot()
+	}
 
 	return payload
 }
 
-func newLogger(level string) *slog.Logger {
-	logLevel := slog.LevelInfo
+// newLogger logs at level, which a config reload may change.
+func newLogger(level *slog.LevelVar) *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: level,
+	}))
+}
+
+func parseLogLevel(level string) slog.Level {
 	switch strings.ToUpper(strings.TrimSpace(level)) {
 	case "DEBUG":
-		logLevel = slog.LevelDebug
+		return slog.LevelDebug
 	case "WARN", "WARNING":
-		logLevel = slog.LevelWarn
+		return slog.LevelWarn
 	case "ERROR":
-		logLevel = slog.LevelError
+		return slog.LevelError
 	}
-
-	return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: logLevel,
-	}))
+	return slog.LevelInfo
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"time"
+
+	"clawkangsar/internal/core"
+	"clawkangsar/internal/health"
+)
+
+// writeMetrics renders the same state as /status for Prometheus.
+func (s *statusTracker) writeMetrics(e *health.Exposition) {
+	s.mu.RLock()
+	gateways := make(map[string]gatewayRuntime, len(s.gateways))
+	for name, state := range s.gateways {
+		gateways[name] = *state
+	}
+	s.mu.RUnlock()
+
+	e.Family("clawkangsar_build_info", "gauge", "Build information.")
+	e.Sample("clawkangsar_build_info", 1, "version", s.version)
+	e.Family("clawkangsar_uptime_seconds", "gauge", "Seconds since the process started.")
+	e.Sample("clawkangsar_uptime_seconds", time.Since(s.started).Seconds())
+
+	e.Family("clawkangsar_gateway_running", "gauge", "Whether a configured gateway is running.")
+	for _, name := range health.SortedKeys(gateways) {
+		e.Sample("clawkangsar_gateway_running", health.Bool(gateways[name].Running), "gateway", name)
+	}
+
+	if s.agent != nil {
+		s.writeAgentMetrics(e, s.agent.Stats())
+	}
+
+	if s.browser != nil {
+		stats := s.browser.Stats()
+		e.Family("clawkangsar_browser_active", "gauge", "Whether the headless browser is running.")
+		e.Sample("clawkangsar_browser_active", health.Bool(stats.Active))
+		e.Family("clawkangsar_browser_launches_total", "counter", "Headless browser launches.")
+		e.Sample("clawkangsar_browser_launches_total", float64(stats.Launches))
+	}
+}
+
+func (s *statusTracker) writeAgentMetrics(e *health.Exposition, stats core.AgentStats) {
+	e.Family("clawkangsar_messages_total", "counter", "Messages received per channel.")
+	for _, channel := range health.SortedKeys(stats.Messages) {
+		e.Sample("clawkangsar_messages_total", float64(stats.Messages[channel]), "channel", channel)
+	}
+
+	e.Family("clawkangsar_sessions", "gauge", "Stored chat sessions.")
+	e.Sample("clawkangsar_sessions", float64(stats.StoredSessions))
+	e.Family("clawkangsar_session_messages", "gauge", "Messages in stored chat sessions.")
+	e.Sample("clawkangsar_session_messages", float64(stats.StoredMessages))
+	e.Family("clawkangsar_memory_messages", "gauge", "Messages held in shared memory.")
+	e.Sample("clawkangsar_memory_messages", float64(stats.InMemoryMessages))
+
+	e.Family("clawkangsar_tool_calls_total", "counter", "Tool executions by tool and outcome.")
+	for _, name := range health.SortedKeys(stats.ToolCalls) {
+		outcomes := stats.ToolCalls[name]
+		for _, outcome := range health.SortedKeys(outcomes) {
+			e.Sample("clawkangsar_tool_calls_total", float64(outcomes[outcome]), "tool", name, "outcome", outcome)
+		}
+	}
+
+	if len(stats.LLMProviders) == 0 {
+		return
+	}
+	e.Family("clawkangsar_llm_requests_total", "counter", "LLM requests per provider and outcome, counting each retry.")
+	for _, provider := range stats.LLMProviders {
+		e.Sample("clawkangsar_llm_requests_total", float64(provider.Answered), "provider", provider.Name, "outcome", "ok")
+		e.Sample("clawkangsar_llm_requests_total", float64(provider.Failures), "provider", provider.Name, "outcome", "error")
+	}
+	e.Family("clawkangsar_llm_request_duration_seconds", "histogram", "LLM request latency per provider.")
+	for _, provider := range stats.LLMProviders {
+		latency := provider.Latency
+		e.Histogram("clawkangsar_llm_request_duration_seconds", core.LatencyBuckets, latency.Counts, latency.Sum, latency.Count, "provider", provider.Name)
+	}
+}
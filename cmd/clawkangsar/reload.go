@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+
+	"clawkangsar/internal/config"
+	"clawkangsar/internal/core"
+)
+
+// reloadResult lists the settings a reload applied and the changed config
+// sections that only take effect after a restart.
+type reloadResult struct {
+	Applied         []string `json:"applied"`
+	RestartRequired []string `json:"restart_required"`
+}
+
+// reloader re-reads the config file on SIGHUP or POST /api/reload and
+// applies the log level, system prompt, access policy and rate limits.
+type reloader struct {
+	mu       sync.Mutex
+	path     string
+	current  config.Config
+	agent    *core.Agent
+	logLevel *slog.LevelVar
+	logger   *slog.Logger
+}
+
+func (r *reloader) Reload() (reloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := config.Load(r.path)
+	if err != nil {
+		return reloadResult{}, err
+	}
+	access, err := core.NewAccessPolicy(next.Access.DefaultRole, next.Access.Users, next.Access.ToolRoles, next.Access.CommandRoles)
+	if err != nil {
+		return reloadResult{}, fmt.Errorf("invalid access config: %w", err)
+	}
+
+	r.logLevel.Set(parseLogLevel(next.LogLevel))
+	r.agent.Reload(core.ReloadOptions{
+		SystemPrompt: next.SystemPrompt,
+		Access:       access,
+		UserRateLimit: core.RateLimit{
+			PerMinute: next.Limits.UserMessagesPerMinute,
+			Burst:     next.Limits.UserBurst,
+		},
+		ChannelRateLimit: core.RateLimit{
+			PerMinute: next.Limits.ChannelMessagesPerMinute,
+			Burst:     next.Limits.ChannelBurst,
+		},
+	})
+
+	// running is the configuration now in effect: the old one with the
+	// reloaded settings replaced. Whatever still differs needs a restart.
+	running := r.current
+	running.LogLevel = next.LogLevel
+	running.SystemPrompt = next.SystemPrompt
+	running.Access = next.Access
+	running.Limits.UserMessagesPerMinute = next.Limits.UserMessagesPerMinute
+	running.Limits.UserBurst = next.Limits.UserBurst
+	running.Limits.ChannelMessagesPerMinute = next.Limits.ChannelMessagesPerMinute
+	running.Limits.ChannelBurst = next.Limits.ChannelBurst
+	r.current = running
+
+	result := reloadResult{
+		Applied:         []string{"log_level", "system_prompt", "access", "limits (rate limits)"},
+		RestartRequired: changedSections(running, next),
+	}
+	r.logger.Info("config reloaded", "path", r.path, "restart_required", strings.Join(result.RestartRequired, ","))
+	return result, nil
+}
+
+// changedSections compares two configs by their top-level JSON keys.
+func changedSections(before config.Config, after config.Config) []string {
+	left, right := configSections(before), configSections(after)
+	changed := make([]string, 0)
+	for key, value := range right {
+		if !bytes.Equal(left[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func configSections(cfg config.Config) map[string]json.RawMessage {
+	sections := make(map[string]json.RawMessage)
+	payload, err := json.Marshal(cfg)
+	if err != nil {
+		return sections
+	}
+	_ = json.Unmarshal(payload, &sections)
+	return sections
+}
@@ -5,46 +5,83 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 )
 
 const defaultSystemPrompt = "You are ClawKangsar, a professional assistant running on a Raspberry Pi. Keep responses concise and use your browser tool only when real-time data is needed."
 
 type Config struct {
-	LogLevel     string         `json:"log_level"`
-	SystemPrompt string         `json:"system_prompt"`
-	LLM          LLMConfig      `json:"llm"`
-	WhatsApp     WhatsAppConfig `json:"whatsapp"`
-	Telegram     TelegramConfig `json:"telegram"`
-	Browser      BrowserConfig  `json:"browser"`
-	Storage      StorageConfig  `json:"storage"`
-	Health       HealthConfig   `json:"health"`
-	Tools        ToolsConfig    `json:"tools"`
+	LogLevel      string              `json:"log_level"`
+	SystemPrompt  string              `json:"system_prompt"`
+	LLM           LLMConfig           `json:"llm"`
+	WhatsApp      WhatsAppConfig      `json:"whatsapp"`
+	Telegram      TelegramConfig      `json:"telegram"`
+	Browser       BrowserConfig       `json:"browser"`
+	Storage       StorageConfig       `json:"storage"`
+	Memory        MemoryConfig        `json:"memory"`
+	Identities    IdentityConfig      `json:"identities"`
+	Access        AccessConfig        `json:"access"`
+	Audit         AuditConfig         `json:"audit"`
+	Usage         UsageConfig         `json:"usage"`
+	Limits        LimitsConfig        `json:"limits"`
+	Scheduler     SchedulerConfig     `json:"scheduler"`
+	Notifications NotificationsConfig `json:"notifications"`
+	Watch         WatchConfig         `json:"watch"`
+	SystemMetrics SystemMetricsConfig `json:"system_metrics"`
+	LogWatch      LogWatchConfig      `json:"log_watch"`
+	Health        HealthConfig        `json:"health"`
+	API           APIConfig           `json:"api"`
+	Dashboard     DashboardConfig     `json:"dashboard"`
+	Tools         ToolsConfig         `json:"tools"`
 }
 
 type WhatsAppConfig struct {
-	Enabled    bool   `json:"enabled"`
-	SessionDSN string `json:"session_dsn"`
+	Enabled     bool     `json:"enabled"`
+	SessionDSN  string   `json:"session_dsn"`
+	AllowList   []string `json:"allow_list"`
+	GroupPolicy string   `json:"group_policy"`
 }
 
 type LLMConfig struct {
-	Enabled         bool    `json:"enabled"`
-	Provider        string  `json:"provider"`
-	AuthMethod      string  `json:"auth_method"`
-	BaseURL         string  `json:"base_url"`
-	APIKey          string  `json:"api_key"`
-	APIKeyEnv       string  `json:"api_key_env"`
-	CodexAuthPath   string  `json:"codex_auth_path"`
-	Model           string  `json:"model"`
-	Temperature     float64 `json:"temperature"`
-	MaxTokens       int     `json:"max_tokens"`
-	TimeoutSeconds  int     `json:"timeout_seconds"`
-	HistoryMessages int     `json:"history_messages"`
+	Enabled         bool                `json:"enabled"`
+	Provider        string              `json:"provider"`
+	AuthMethod      string              `json:"auth_method"`
+	BaseURL         string              `json:"base_url"`
+	APIKey          string              `json:"api_key"`
+	APIKeyEnv       string              `json:"api_key_env"`
+	CodexAuthPath   string              `json:"codex_auth_path"`
+	Model           string              `json:"model"`
+	Temperature     float64             `json:"temperature"`
+	MaxTokens       int                 `json:"max_tokens"`
+	TimeoutSeconds  int                 `json:"timeout_seconds"`
+	HistoryMessages int                 `json:"history_messages"`
+	ContextTokens   int                 `json:"context_tokens"`
+	SummarizeAfter  int                 `json:"summarize_after_messages"`
+	KeepAlive       string              `json:"keep_alive"`
+	MaxRetries      int                 `json:"max_retries"`
+	RetryBackoffMS  int                 `json:"retry_backoff_ms"`
+	Fallbacks       []LLMFallbackConfig `json:"fallbacks"`
+}
+
+// LLMFallbackConfig is one extra provider tried, in order, when the primary
+// provider keeps failing. Empty fields are inherited as described in Chain.
+type LLMFallbackConfig struct {
+	Provider      string `json:"provider"`
+	AuthMethod    string `json:"auth_method"`
+	BaseURL       string `json:"base_url"`
+	APIKey        string `json:"api_key"`
+	APIKeyEnv     string `json:"api_key_env"`
+	CodexAuthPath string `json:"codex_auth_path"`
+	Model         string `json:"model"`
+	KeepAlive     string `json:"keep_alive"`
 }
 
 type TelegramConfig struct {
-	Enabled   bool    `json:"enabled"`
-	Token     string  `json:"token"`
-	AllowList []int64 `json:"allow_list"`
+	Enabled              bool    `json:"enabled"`
+	Token                string  `json:"token"`
+	AllowList            []int64 `json:"allow_list"`
+	StreamReplies        bool    `json:"stream_replies"`
+	StreamEditIntervalMS int     `json:"stream_edit_interval_ms"`
 }
 
 type BrowserConfig struct {
@@ -52,29 +89,218 @@ type BrowserConfig struct {
 }
 
 type StorageConfig struct {
-	SessionDir string `json:"session_dir"`
+	SessionBackend       string `json:"session_backend"`
+	SessionDir           string `json:"session_dir"`
+	SessionDB            string `json:"session_db"`
+	MaxSessionAgeDays    int    `json:"max_session_age_days"`
+	MaxSessionMessages   int    `json:"max_session_messages"`
+	MaxTotalBytes        int64  `json:"max_total_bytes"`
+	PruneIntervalMinutes int    `json:"prune_interval_minutes"`
+}
+
+type MemoryConfig struct {
+	Sharing string `json:"sharing"`
+}
+
+type IdentityConfig struct {
+	LinksPath       string         `json:"links_path"`
+	LinkCodeMinutes int            `json:"link_code_minutes"`
+	People          []PersonConfig `json:"people"`
+}
+
+type PersonConfig struct {
+	ID       string   `json:"id"`
+	Telegram []int64  `json:"telegram"`
+	WhatsApp []string `json:"whatsapp"`
+}
+
+type AccessConfig struct {
+	DefaultRole  string            `json:"default_role"`
+	Users        map[string]string `json:"users"`
+	ToolRoles    map[string]string `json:"tool_roles"`
+	CommandRoles map[string]string `json:"command_roles"`
+}
+
+type AuditConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Path     string `json:"path"`
+	MaxBytes int64  `json:"max_bytes"`
+	MaxFiles int    `json:"max_files"`
+}
+
+// UsageConfig controls token accounting. Prices are per million tokens and
+// keyed by model name; "*" applies to models without their own entry. Budgets
+// are per local day and zero means unlimited.
+type UsageConfig struct {
+	Enabled              bool                  `json:"enabled"`
+	Path                 string                `json:"path"`
+	RetainDays           int                   `json:"retain_days"`
+	Prices               map[string]ModelPrice `json:"prices"`
+	DailyTokenBudget     int64                 `json:"daily_token_budget"`
+	DailyCostBudget      float64               `json:"daily_cost_budget"`
+	UserDailyTokenBudget int64                 `json:"user_daily_token_budget"`
+	UserDailyCostBudget  float64               `json:"user_daily_cost_budget"`
+}
+
+type ModelPrice struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// LimitsConfig throttles incoming messages and LLM load. A zero rate turns
+// that limit off; max_concurrent_llm 0 means no cap.
+type LimitsConfig struct {
+	UserMessagesPerMinute    float64 `json:"user_messages_per_minute"`
+	UserBurst                int     `json:"user_burst"`
+	ChannelMessagesPerMinute float64 `json:"channel_messages_per_minute"`
+	ChannelBurst             int     `json:"channel_burst"`
+	MaxConcurrentLLM         int     `json:"max_concurrent_llm"`
+}
+
+type SchedulerConfig struct {
+	Enabled        bool   `json:"enabled"`
+	Path           string `json:"path"`
+	MaxJobsPerUser int    `json:"max_jobs_per_user"`
+}
+
+// NotificationsConfig routes outbound messages. Preferred maps an identity or
+// "<channel>:<user id>" account to its preferred channel; everyone else is
+// reached in ChannelOrder. The health /notify endpoint is enabled only when a
+// token is set.
+type NotificationsConfig struct {
+	Token        string            `json:"token"`
+	TokenEnv     string            `json:"token_env"`
+	ChannelOrder []string          `json:"channel_order"`
+	Preferred    map[string]string `json:"preferred"`
+}
+
+// ResolvedToken returns Token or, when empty, the value of TokenEnv.
+func (c NotificationsConfig) ResolvedToken() string {
+	return resolveToken(c.Token, c.TokenEnv)
+}
+
+// WatchConfig polls services and containers and alerts Chats, each written as
+// "<channel>:<chat id>", when one changes state or keeps restarting. Empty
+// Services or Containers lists watch every allow-listed one.
+type WatchConfig struct {
+	Enabled            bool     `json:"enabled"`
+	IntervalSeconds    int      `json:"interval_seconds"`
+	Services           []string `json:"services"`
+	Containers         []string `json:"containers"`
+	Chats              []string `json:"chats"`
+	LogLines           int      `json:"log_lines"`
+	RestartLoopCount   int      `json:"restart_loop_count"`
+	RestartLoopMinutes int      `json:"restart_loop_minutes"`
 }
 
+// SystemMetricsConfig controls the system_metrics tool and threshold alerts
+// to Chats. A zero threshold turns that alert off; an alert clears once the
+// value is HysteresisPercent below its threshold.
+type SystemMetricsConfig struct {
+	ToolEnabled       bool     `json:"tool_enabled"`
+	AlertsEnabled     bool     `json:"alerts_enabled"`
+	IntervalSeconds   int      `json:"interval_seconds"`
+	Mounts            []string `json:"mounts"`
+	Chats             []string `json:"chats"`
+	LoadPerCPU        float64  `json:"load_per_cpu"`
+	TemperatureC      float64  `json:"temperature_c"`
+	MemoryPercent     float64  `json:"memory_percent"`
+	DiskPercent       float64  `json:"disk_percent"`
+	HysteresisPercent float64  `json:"hysteresis_percent"`
+	AlertThrottling   bool     `json:"alert_throttling"`
+}
+
+// LogWatchConfig follows journal units and containers and alerts Chats on
+// lines matching Rules. Empty Units or Containers lists follow every
+// allow-listed one.
+type LogWatchConfig struct {
+	Enabled      bool            `json:"enabled"`
+	Units        []string        `json:"units"`
+	Containers   []string        `json:"containers"`
+	Chats        []string        `json:"chats"`
+	ContextLines int             `json:"context_lines"`
+	Rules        []LogRuleConfig `json:"rules"`
+}
+
+// LogRuleConfig is one regular expression. Sources limits it to some units or
+// containers and Chats overrides LogWatchConfig.Chats.
+type LogRuleConfig struct {
+	Name            string   `json:"name"`
+	Pattern         string   `json:"pattern"`
+	Sources         []string `json:"sources,omitempty"`
+	Chats           []string `json:"chats,omitempty"`
+	CooldownSeconds int      `json:"cooldown_seconds"`
+}
+
+// HealthConfig serves plain HTTP unless TLSCertFile and TLSKeyFile are set.
+// With ClientCAFile, client certificates signed by that CA are accepted in
+// place of the API token.
 type HealthConfig struct {
-	Enabled bool   `json:"enabled"`
-	Host    string `json:"host"`
-	Port    int    `json:"port"`
+	Enabled        bool   `json:"enabled"`
+	Host           string `json:"host"`
+	Port           int    `json:"port"`
+	MetricsEnabled bool   `json:"metrics_enabled"`
+	TLSCertFile    string `json:"tls_cert_file"`
+	TLSKeyFile     string `json:"tls_key_file"`
+	ClientCAFile   string `json:"client_ca_file"`
+}
+
+// APIConfig enables the admin REST API under /api on the health server. It
+// needs a token or a health.client_ca_file to accept any request.
+type APIConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Token    string `json:"token"`
+	TokenEnv string `json:"token_env"`
+}
+
+// ResolvedToken returns Token or, when empty, the value of TokenEnv.
+func (c APIConfig) ResolvedToken() string {
+	return resolveToken(c.Token, c.TokenEnv)
+}
+
+// DashboardConfig serves the web dashboard at /dashboard/ on the health
+// server. Login is refused until a password is set in Password or the
+// variable named by PasswordEnv.
+type DashboardConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	PasswordEnv  string `json:"password_env"`
+	SessionHours int    `json:"session_hours"`
+}
+
+// ResolvedPassword returns Password or, when empty, the value of PasswordEnv.
+func (c DashboardConfig) ResolvedPassword() string {
+	return resolveToken(c.Password, c.PasswordEnv)
+}
+
+func resolveToken(token string, env string) string {
+	if token = strings.TrimSpace(token); token != "" {
+		return token
+	}
+	if strings.TrimSpace(env) == "" {
+		return ""
+	}
+	return strings.TrimSpace(os.Getenv(env))
 }
 
 type ToolsConfig struct {
-	WebFetchTimeoutSeconds int               `json:"web_fetch_timeout_seconds"`
-	WebFetchMaxChars       int               `json:"web_fetch_max_chars"`
-	CommandTimeoutSeconds  int               `json:"command_timeout_seconds"`
-	DefaultLogLines        int               `json:"default_log_lines"`
-	MaxLogLines            int               `json:"max_log_lines"`
-	ShellEnabled           bool              `json:"shell_enabled"`
-	ShellCommands          map[string]string `json:"shell_commands"`
-	SystemctlEnabled       bool              `json:"systemctl_enabled"`
-	SystemctlAllowServices []string          `json:"systemctl_allow_services"`
-	DockerEnabled          bool              `json:"docker_enabled"`
-	DockerAllowContainers  []string          `json:"docker_allow_containers"`
-	JournalEnabled         bool              `json:"journal_enabled"`
-	JournalAllowUnits      []string          `json:"journal_allow_units"`
+	WebFetchTimeoutSeconds   int                 `json:"web_fetch_timeout_seconds"`
+	WebFetchMaxChars         int                 `json:"web_fetch_max_chars"`
+	CommandTimeoutSeconds    int                 `json:"command_timeout_seconds"`
+	DefaultLogLines          int                 `json:"default_log_lines"`
+	MaxLogLines              int                 `json:"max_log_lines"`
+	ShellEnabled             bool                `json:"shell_enabled"`
+	ShellCommands            map[string]string   `json:"shell_commands"`
+	SystemctlEnabled         bool                `json:"systemctl_enabled"`
+	SystemctlAllowServices   []string            `json:"systemctl_allow_services"`
+	SystemctlConfirmActions  []string            `json:"systemctl_confirm_actions"`
+	SystemctlConfirmServices map[string][]string `json:"systemctl_confirm_services"`
+	ConfirmTimeoutSeconds    int                 `json:"confirm_timeout_seconds"`
+	DockerEnabled            bool                `json:"docker_enabled"`
+	DockerAllowContainers    []string            `json:"docker_allow_containers"`
+	JournalEnabled           bool                `json:"journal_enabled"`
+	JournalAllowUnits        []string            `json:"journal_allow_units"`
 }
 
 func Default() Config {
@@ -94,41 +320,148 @@ func Default() Config {
 			MaxTokens:       512,
 			TimeoutSeconds:  60,
 			HistoryMessages: 16,
+			ContextTokens:   0,
+			SummarizeAfter:  32,
+			KeepAlive:       "",
+			MaxRetries:      2,
+			RetryBackoffMS:  500,
+			Fallbacks:       []LLMFallbackConfig{},
 		},
 		WhatsApp: WhatsAppConfig{
-			Enabled:    false,
-			SessionDSN: "file:clawkangsar_whatsapp.db?_foreign_keys=on",
+			Enabled:     false,
+			SessionDSN:  "file:clawkangsar_whatsapp.db?_foreign_keys=on",
+			AllowList:   []string{},
+			GroupPolicy: "ignore",
 		},
 		Telegram: TelegramConfig{
-			Enabled:   false,
-			Token:     "",
-			AllowList: []int64{},
+			Enabled:              false,
+			Token:                "",
+			AllowList:            []int64{},
+			StreamReplies:        true,
+			StreamEditIntervalMS: 1000,
 		},
 		Browser: BrowserConfig{
 			IdleTimeoutSeconds: 300,
 		},
 		Storage: StorageConfig{
-			SessionDir: "data/sessions",
+			SessionBackend:       "json",
+			SessionDir:           "data/sessions",
+			SessionDB:            "data/sessions.db",
+			MaxSessionAgeDays:    0,
+			MaxSessionMessages:   0,
+			MaxTotalBytes:        0,
+			PruneIntervalMinutes: 60,
+		},
+		Audit: AuditConfig{
+			Enabled:  true,
+			Path:     "data/audit.jsonl",
+			MaxBytes: 10 << 20,
+			MaxFiles: 5,
+		},
+		Usage: UsageConfig{
+			Enabled:    true,
+			Path:       "data/usage.json",
+			RetainDays: 90,
+			Prices:     map[string]ModelPrice{},
+		},
+		Limits: LimitsConfig{
+			UserMessagesPerMinute:    10,
+			UserBurst:                5,
+			ChannelMessagesPerMinute: 60,
+			ChannelBurst:             20,
+			MaxConcurrentLLM:         2,
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:        true,
+			Path:           "data/schedules.json",
+			MaxJobsPerUser: 20,
+		},
+		Notifications: NotificationsConfig{
+			TokenEnv:     "CLAWKANGSAR_NOTIFY_TOKEN",
+			ChannelOrder: []string{"telegram", "whatsapp"},
+			Preferred:    map[string]string{},
+		},
+		Watch: WatchConfig{
+			Enabled:            false,
+			IntervalSeconds:    60,
+			Services:           []string{},
+			Containers:         []string{},
+			Chats:              []string{},
+			LogLines:           15,
+			RestartLoopCount:   3,
+			RestartLoopMinutes: 10,
+		},
+		SystemMetrics: SystemMetricsConfig{
+			ToolEnabled:       true,
+			AlertsEnabled:     false,
+			IntervalSeconds:   60,
+			Mounts:            []string{"/"},
+			Chats:             []string{},
+			LoadPerCPU:        2,
+			TemperatureC:      75,
+			MemoryPercent:     90,
+			DiskPercent:       90,
+			HysteresisPercent: 10,
+			AlertThrottling:   true,
+		},
+		LogWatch: LogWatchConfig{
+			Enabled:      false,
+			Units:        []string{},
+			Containers:   []string{},
+			Chats:        []string{},
+			ContextLines: 3,
+			Rules: []LogRuleConfig{
+				{Name: "oom", Pattern: "(?i)out of memory|oom-kill|killed by the oom killer", CooldownSeconds: 300},
+				{Name: "segfault", Pattern: "(?i)segfault|segmentation fault", CooldownSeconds: 300},
+			},
+		},
+		Memory: MemoryConfig{
+			Sharing: "isolated",
+		},
+		Identities: IdentityConfig{
+			LinksPath:       "data/identities.json",
+			LinkCodeMinutes: 10,
+			People:          []PersonConfig{},
+		},
+		Access: AccessConfig{
+			DefaultRole:  "viewer",
+			Users:        map[string]string{},
+			ToolRoles:    map[string]string{},
+			CommandRoles: map[string]string{},
 		},
 		Health: HealthConfig{
-			Enabled: true,
-			Host:    "0.0.0.0",
-			Port:    18080,
+			Enabled:        true,
+			Host:           "0.0.0.0",
+			Port:           18080,
+			MetricsEnabled: true,
+		},
+		API: APIConfig{
+			Enabled:  false,
+			TokenEnv: "CLAWKANGSAR_API_TOKEN",
+		},
+		Dashboard: DashboardConfig{
+			Enabled:      false,
+			Username:     "admin",
+			PasswordEnv:  "CLAWKANGSAR_DASHBOARD_PASSWORD",
+			SessionHours: 12,
 		},
 		Tools: ToolsConfig{
-			WebFetchTimeoutSeconds: 20,
-			WebFetchMaxChars:       4000,
-			CommandTimeoutSeconds:  20,
-			DefaultLogLines:        80,
-			MaxLogLines:            200,
-			ShellEnabled:           false,
-			ShellCommands:          map[string]string{},
-			SystemctlEnabled:       false,
-			SystemctlAllowServices: []string{},
-			DockerEnabled:          false,
-			DockerAllowContainers:  []string{},
-			JournalEnabled:         false,
-			JournalAllowUnits:      []string{},
+			WebFetchTimeoutSeconds:   20,
+			WebFetchMaxChars:         4000,
+			CommandTimeoutSeconds:    20,
+			DefaultLogLines:          80,
+			MaxLogLines:              200,
+			ShellEnabled:             false,
+			ShellCommands:            map[string]string{},
+			SystemctlEnabled:         false,
+			SystemctlAllowServices:   []string{},
+			SystemctlConfirmActions:  []string{"stop", "restart"},
+			SystemctlConfirmServices: map[string][]string{},
+			ConfirmTimeoutSeconds:    120,
+			DockerEnabled:            false,
+			DockerAllowContainers:    []string{},
+			JournalEnabled:           false,
+			JournalAllowUnits:        []string{},
 		},
 	}
 }
@@ -181,24 +514,203 @@ func (c *Config) Normalize() {
 	if c.LLM.HistoryMessages <= 0 {
 		c.LLM.HistoryMessages = defaults.LLM.HistoryMessages
 	}
+	if c.LLM.SummarizeAfter < 0 {
+		c.LLM.SummarizeAfter = 0
+	}
+	if c.LLM.ContextTokens < 0 {
+		c.LLM.ContextTokens = defaults.LLM.ContextTokens
+	}
 	if c.LLM.Temperature < 0 {
 		c.LLM.Temperature = defaults.LLM.Temperature
 	}
+	if c.LLM.MaxRetries < 0 {
+		c.LLM.MaxRetries = 0
+	}
+	if c.LLM.RetryBackoffMS <= 0 {
+		c.LLM.RetryBackoffMS = defaults.LLM.RetryBackoffMS
+	}
+	if c.LLM.Fallbacks == nil {
+		c.LLM.Fallbacks = []LLMFallbackConfig{}
+	}
 	if c.WhatsApp.SessionDSN == "" {
 		c.WhatsApp.SessionDSN = defaults.WhatsApp.SessionDSN
 	}
+	if c.WhatsApp.AllowList == nil {
+		c.WhatsApp.AllowList = []string{}
+	}
+	if c.WhatsApp.GroupPolicy == "" {
+		c.WhatsApp.GroupPolicy = defaults.WhatsApp.GroupPolicy
+	}
 	if c.Browser.IdleTimeoutSeconds <= 0 {
 		c.Browser.IdleTimeoutSeconds = defaults.Browser.IdleTimeoutSeconds
 	}
+	if c.Storage.SessionBackend == "" {
+		c.Storage.SessionBackend = defaults.Storage.SessionBackend
+	}
 	if c.Storage.SessionDir == "" {
 		c.Storage.SessionDir = defaults.Storage.SessionDir
 	}
+	if c.Storage.SessionDB == "" {
+		c.Storage.SessionDB = defaults.Storage.SessionDB
+	}
+	if c.Storage.MaxSessionAgeDays < 0 {
+		c.Storage.MaxSessionAgeDays = 0
+	}
+	if c.Storage.MaxSessionMessages < 0 {
+		c.Storage.MaxSessionMessages = 0
+	}
+	if c.Storage.MaxTotalBytes < 0 {
+		c.Storage.MaxTotalBytes = 0
+	}
+	if c.Storage.PruneIntervalMinutes <= 0 {
+		c.Storage.PruneIntervalMinutes = defaults.Storage.PruneIntervalMinutes
+	}
+	if c.Audit.Path == "" {
+		c.Audit.Path = defaults.Audit.Path
+	}
+	if c.Audit.MaxBytes <= 0 {
+		c.Audit.MaxBytes = defaults.Audit.MaxBytes
+	}
+	if c.Audit.MaxFiles < 0 {
+		c.Audit.MaxFiles = 0
+	}
+	if c.Usage.Path == "" {
+		c.Usage.Path = defaults.Usage.Path
+	}
+	if c.Usage.RetainDays <= 0 {
+		c.Usage.RetainDays = defaults.Usage.RetainDays
+	}
+	if c.Usage.Prices == nil {
+		c.Usage.Prices = map[string]ModelPrice{}
+	}
+	if c.Usage.DailyTokenBudget < 0 {
+		c.Usage.DailyTokenBudget = 0
+	}
+	if c.Usage.DailyCostBudget < 0 {
+		c.Usage.DailyCostBudget = 0
+	}
+	if c.Usage.UserDailyTokenBudget < 0 {
+		c.Usage.UserDailyTokenBudget = 0
+	}
+	if c.Usage.UserDailyCostBudget < 0 {
+		c.Usage.UserDailyCostBudget = 0
+	}
+	if c.Limits.UserMessagesPerMinute < 0 {
+		c.Limits.UserMessagesPerMinute = 0
+	}
+	if c.Limits.UserBurst < 0 {
+		c.Limits.UserBurst = 0
+	}
+	if c.Limits.ChannelMessagesPerMinute < 0 {
+		c.Limits.ChannelMessagesPerMinute = 0
+	}
+	if c.Limits.ChannelBurst < 0 {
+		c.Limits.ChannelBurst = 0
+	}
+	if c.Limits.MaxConcurrentLLM < 0 {
+		c.Limits.MaxConcurrentLLM = 0
+	}
+	if c.Scheduler.Path == "" {
+		c.Scheduler.Path = defaults.Scheduler.Path
+	}
+	if c.Scheduler.MaxJobsPerUser < 0 {
+		c.Scheduler.MaxJobsPerUser = 0
+	}
+	if len(c.Notifications.ChannelOrder) == 0 {
+		c.Notifications.ChannelOrder = defaults.Notifications.ChannelOrder
+	}
+	if c.Notifications.Preferred == nil {
+		c.Notifications.Preferred = map[string]string{}
+	}
+	if c.Watch.IntervalSeconds <= 0 {
+		c.Watch.IntervalSeconds = defaults.Watch.IntervalSeconds
+	}
+	if c.Watch.Services == nil {
+		c.Watch.Services = []string{}
+	}
+	if c.Watch.Containers == nil {
+		c.Watch.Containers = []string{}
+	}
+	if c.Watch.Chats == nil {
+		c.Watch.Chats = []string{}
+	}
+	if c.Watch.LogLines < 0 {
+		c.Watch.LogLines = 0
+	}
+	if c.Watch.RestartLoopCount < 0 {
+		c.Watch.RestartLoopCount = 0
+	}
+	if c.Watch.RestartLoopMinutes <= 0 {
+		c.Watch.RestartLoopMinutes = defaults.Watch.RestartLoopMinutes
+	}
+	if c.SystemMetrics.IntervalSeconds <= 0 {
+		c.SystemMetrics.IntervalSeconds = defaults.SystemMetrics.IntervalSeconds
+	}
+	if len(c.SystemMetrics.Mounts) == 0 {
+		c.SystemMetrics.Mounts = append([]string{}, defaults.SystemMetrics.Mounts...)
+	}
+	if c.SystemMetrics.Chats == nil {
+		c.SystemMetrics.Chats = []string{}
+	}
+	if c.SystemMetrics.HysteresisPercent < 0 {
+		c.SystemMetrics.HysteresisPercent = 0
+	}
+	if c.LogWatch.Units == nil {
+		c.LogWatch.Units = []string{}
+	}
+	if c.LogWatch.Containers == nil {
+		c.LogWatch.Containers = []string{}
+	}
+	if c.LogWatch.Chats == nil {
+		c.LogWatch.Chats = []string{}
+	}
+	if c.LogWatch.ContextLines < 0 {
+		c.LogWatch.ContextLines = 0
+	}
+	if c.LogWatch.Rules == nil {
+		c.LogWatch.Rules = append([]LogRuleConfig{}, defaults.LogWatch.Rules...)
+	}
+	for i := range c.LogWatch.Rules {
+		if c.LogWatch.Rules[i].CooldownSeconds <= 0 {
+			c.LogWatch.Rules[i].CooldownSeconds = 300
+		}
+	}
+	if c.Memory.Sharing == "" {
+		c.Memory.Sharing = defaults.Memory.Sharing
+	}
+	if c.Identities.LinksPath == "" {
+		c.Identities.LinksPath = defaults.Identities.LinksPath
+	}
+	if c.Identities.LinkCodeMinutes <= 0 {
+		c.Identities.LinkCodeMinutes = defaults.Identities.LinkCodeMinutes
+	}
+	if c.Identities.People == nil {
+		c.Identities.People = []PersonConfig{}
+	}
+	if c.Access.DefaultRole == "" {
+		c.Access.DefaultRole = defaults.Access.DefaultRole
+	}
+	if c.Access.Users == nil {
+		c.Access.Users = map[string]string{}
+	}
+	if c.Access.ToolRoles == nil {
+		c.Access.ToolRoles = map[string]string{}
+	}
+	if c.Access.CommandRoles == nil {
+		c.Access.CommandRoles = map[string]string{}
+	}
 	if c.Health.Host == "" {
 		c.Health.Host = defaults.Health.Host
 	}
 	if c.Health.Port <= 0 {
 		c.Health.Port = defaults.Health.Port
 	}
+	if strings.TrimSpace(c.Dashboard.Username) == "" {
+		c.Dashboard.Username = defaults.Dashboard.Username
+	}
+	if c.Dashboard.SessionHours <= 0 {
+		c.Dashboard.SessionHours = defaults.Dashboard.SessionHours
+	}
 	if c.Tools.WebFetchTimeoutSeconds <= 0 {
 		c.Tools.WebFetchTimeoutSeconds = defaults.Tools.WebFetchTimeoutSeconds
 	}
@@ -223,6 +735,15 @@ func (c *Config) Normalize() {
 	if c.Tools.SystemctlAllowServices == nil {
 		c.Tools.SystemctlAllowServices = []string{}
 	}
+	if c.Tools.SystemctlConfirmActions == nil {
+		c.Tools.SystemctlConfirmActions = append([]string{}, defaults.Tools.SystemctlConfirmActions...)
+	}
+	if c.Tools.SystemctlConfirmServices == nil {
+		c.Tools.SystemctlConfirmServices = map[string][]string{}
+	}
+	if c.Tools.ConfirmTimeoutSeconds <= 0 {
+		c.Tools.ConfirmTimeoutSeconds = defaults.Tools.ConfirmTimeoutSeconds
+	}
 	if c.Tools.DockerAllowContainers == nil {
 		c.Tools.DockerAllowContainers = []string{}
 	}
@@ -232,4 +753,57 @@ func (c *Config) Normalize() {
 	if c.Telegram.AllowList == nil {
 		c.Telegram.AllowList = []int64{}
 	}
+	if c.Telegram.StreamEditIntervalMS <= 0 {
+		c.Telegram.StreamEditIntervalMS = defaults.Telegram.StreamEditIntervalMS
+	}
+}
+
+// Chain returns the primary LLM config followed by one config per fallback.
+// Fallbacks always inherit generation settings such as max_tokens. Connection
+// settings are inherited only when the fallback uses the primary's provider;
+// otherwise unset fields take the provider's own defaults. The model is always
+// inherited when unset, so a fallback on another provider must set its own.
+func (c LLMConfig) Chain() []LLMConfig {
+	chain := make([]LLMConfig, 0, 1+len(c.Fallbacks))
+	primary := c
+	primary.Fallbacks = nil
+	chain = append(chain, primary)
+
+	defaults := Default().LLM
+	for _, fallback := range c.Fallbacks {
+		entry := primary
+		provider := strings.TrimSpace(fallback.Provider)
+		if provider != "" && !strings.EqualFold(provider, primary.Provider) {
+			entry.Provider = provider
+			entry.AuthMethod = ""
+			entry.BaseURL = defaults.BaseURL
+			entry.APIKey = ""
+			entry.APIKeyEnv = defaults.APIKeyEnv
+			entry.CodexAuthPath = ""
+			entry.KeepAlive = ""
+		}
+		if fallback.AuthMethod != "" {
+			entry.AuthMethod = fallback.AuthMethod
+		}
+		if fallback.BaseURL != "" {
+			entry.BaseURL = fallback.BaseURL
+		}
+		if fallback.APIKey != "" {
+			entry.APIKey = fallback.APIKey
+		}
+		if fallback.APIKeyEnv != "" {
+			entry.APIKeyEnv = fallback.APIKeyEnv
+		}
+		if fallback.CodexAuthPath != "" {
+			entry.CodexAuthPath = fallback.CodexAuthPath
+		}
+		if fallback.Model != "" {
+			entry.Model = fallback.Model
+		}
+		if fallback.KeepAlive != "" {
+			entry.KeepAlive = fallback.KeepAlive
+		}
+		chain = append(chain, entry)
+	}
+	return chain
 }
@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Role orders what a caller may do. Higher roles include all lower ones.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleViewer
+	RoleOperator
+	RoleAdmin
+)
+
+func ParseRole(value string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "none":
+		return RoleNone, nil
+	case "viewer":
+		return RoleViewer, nil
+	case "operator":
+		return RoleOperator, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RoleNone, fmt.Errorf("unknown role %q; use none, viewer, operator or admin", value)
+	}
+}
+
+func (r Role) String() string {
+	switch r {
+	case RoleViewer:
+		return "viewer"
+	case RoleOperator:
+		return "operator"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// AccessPolicy assigns roles to callers and optionally overrides the roles
+// tools and commands declare for themselves.
+type AccessPolicy struct {
+	DefaultRole  Role
+	Users        map[string]Role
+	ToolRoles    map[string]Role
+	CommandRoles map[string]Role
+}
+
+// NewAccessPolicy parses role names from config. User keys are either
+// "<channel>:<user id>" accounts or identity IDs.
+func NewAccessPolicy(defaultRole string, users map[string]string, toolRoles map[string]string, commandRoles map[string]string) (*AccessPolicy, error) {
+	role, err := ParseRole(defaultRole)
+	if err != nil {
+		return nil, fmt.Errorf("default role: %w", err)
+	}
+
+	policy := &AccessPolicy{
+		DefaultRole:  role,
+		Users:        make(map[string]Role, len(users)),
+		ToolRoles:    make(map[string]Role, len(toolRoles)),
+		CommandRoles: make(map[string]Role, len(commandRoles)),
+	}
+	for key, value := range users {
+		role, err := ParseRole(value)
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", key, err)
+		}
+		if channel, userID, ok := strings.Cut(key, ":"); ok {
+			key = NormalizeAccount(channel, userID)
+		}
+		policy.Users[strings.TrimSpace(key)] = role
+	}
+	for name, value := range toolRoles {
+		role, err := ParseRole(value)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", name, err)
+		}
+		policy.ToolRoles[strings.TrimSpace(name)] = role
+	}
+	for name, value := range commandRoles {
+		role, err := ParseRole(value)
+		if err != nil {
+			return nil, fmt.Errorf("command %s: %w", name, err)
+		}
+		policy.CommandRoles[strings.ToLower(strings.TrimSpace(name))] = role
+	}
+	return policy, nil
+}
+
+// roleFor returns the highest role granted to the account, its identity, or
+// any other account linked to that identity.
+func (a *Agent) roleFor(msg Message) Role {
+	access := a.settings.Load().access
+	if access == nil {
+		return RoleAdmin
+	}
+
+	role := access.DefaultRole
+	grant := func(key string) {
+		if granted, ok := access.Users[key]; ok && granted > role {
+			role = granted
+		}
+	}
+
+	grant(NormalizeAccount(msg.Channel, msg.UserID))
+	if identity, ok := a.resolveIdentity(msg); ok {
+		grant(identity)
+		if store, ok := a.identities.(*IdentityStore); ok {
+			for _, account := range store.Accounts(identity) {
+				grant(account)
+			}
+		}
+	}
+	return role
+}
+
+func (a *Agent) toolRole(tool Tool) Role {
+	if access := a.settings.Load().access; access != nil {
+		if role, ok := access.ToolRoles[tool.Name()]; ok {
+			return role
+		}
+	}
+	return tool.RequiredRole()
+}
+
+func (a *Agent) commandRole(name string, declared Role) Role {
+	if access := a.settings.Load().access; access != nil {
+		if role, ok := access.CommandRoles[strings.ToLower(name)]; ok {
+			return role
+		}
+	}
+	return declared
+}
+
+type caller struct {
+	msg        Message
+	sessionKey string
+	role       Role
+	confirmed  bool
+	origin     string
+	onPartial  PartialFunc
+}
+
+type callerKey struct{}
+
+// withCaller lets tools that act on behalf of the caller, such as
+// schedule_task, see who invoked them.
+func withCaller(ctx context.Context, c caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+func callerFrom(ctx context.Context) (caller, bool) {
+	c, ok := ctx.Value(callerKey{}).(caller)
+	return c, ok
+}
+
+func (a *Agent) permits(c caller, required Role) bool {
+	return c.role >= required
+}
+
+func (a *Agent) isBuiltinCommand(name string) bool {
+	switch name {
+	case "/status", "/reset", "/forget", "/compact", "/link", "/unlink", "/audit", "/schedule", "/schedules", "/unschedule", ConfirmCommand:
+		return true
+	default:
+		return false
+	}
+}
+
+// builtinCommandRole is the default role for agent-handled commands. The
+// audit trail exposes other users' activity, so it is admin-only.
+func builtinCommandRole(name string) Role {
+	if name == "/audit" {
+		return RoleAdmin
+	}
+	return RoleViewer
+}
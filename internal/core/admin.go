@@ -0,0 +1,66 @@
+package core
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// apiCaller is the account used for tool calls made through the admin API.
+var apiCaller = Message{Channel: AuditOriginAPI, UserID: "admin"}
+
+// DeleteSession clears a stored session like /reset does, waiting for any
+// reply in progress for it. It returns how many stored messages it held.
+func (a *Agent) DeleteSession(ctx context.Context, sessionKey string) (int, error) {
+	done, err := a.queue.acquire(ctx, sessionKey)
+	if err != nil {
+		return 0, err
+	}
+	defer done()
+
+	removed, err := a.deleteSession(sessionKey)
+	a.RecordAPIAction("delete_session", map[string]any{"session": sessionKey}, err)
+	return removed, err
+}
+
+// RecordAPIAction audits an admin API action that does not run a tool, such
+// as a config reload.
+func (a *Agent) RecordAPIAction(action string, arguments map[string]any, err error) {
+	a.recordCommand(newAPICaller(), action, arguments, AuditStatusOK, err)
+}
+
+// UsageSummary returns the usage of day (YYYY-MM-DD, empty for today) with
+// the breakdown by user and session that Stats leaves out.
+func (a *Agent) UsageSummary(day string) UsageSummary {
+	return a.usage.Summary(day)
+}
+
+// ToolDefinitions returns every available tool, whatever its role.
+func (a *Agent) ToolDefinitions() []ToolDefinition {
+	return a.availableTools(RoleAdmin)
+}
+
+// CallTool runs a tool for the admin API. The API is authenticated
+// separately, so the call runs as admin and skips confirmations; it is
+// audited with the api origin.
+func (a *Agent) CallTool(ctx context.Context, name string, arguments map[string]any) (string, error) {
+	c := newAPICaller()
+	call := ToolCall{
+		ID:        "api_" + strconv.FormatInt(c.msg.Timestamp.UnixNano(), 36),
+		Name:      name,
+		Arguments: arguments,
+	}
+	return a.runTool(ctx, c, call)
+}
+
+func newAPICaller() caller {
+	msg := apiCaller
+	msg.Timestamp = time.Now()
+	return caller{
+		msg:        msg,
+		sessionKey: messageSessionKey(msg),
+		role:       RoleAdmin,
+		confirmed:  true,
+		origin:     AuditOriginAPI,
+	}
+}
@@ -3,73 +3,155 @@ package core
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type BrowserTool interface {
-	Browse(ctx context.Context, rawURL string) (string, error)
-}
+const defaultSystemPrompt = "You are ClawKangsar, a professional assistant running on a Raspberry Pi. Keep responses concise and use your browser tool only when real-time data is needed."
 
-type WebFetchTool interface {
-	Fetch(ctx context.Context, rawURL string) (string, error)
+type AgentStats struct {
+	InMemoryMessages int             `json:"in_memory_messages"`
+	StoredSessions   int             `json:"stored_sessions"`
+	StoredMessages   int             `json:"stored_messages"`
+	LLMProviders     []ProviderStats `json:"llm_providers,omitempty"`
+	Usage            *UsageSummary   `json:"usage,omitempty"`
+	// Messages counts received messages per channel and ToolCalls counts
+	// tool runs per tool and audit status.
+	Messages  map[string]int            `json:"messages,omitempty"`
+	ToolCalls map[string]map[string]int `json:"tool_calls,omitempty"`
 }
 
-type ServerTool interface {
-	ShellAvailable() bool
-	SystemctlAvailable() bool
-	DockerAvailable() bool
-	JournalAvailable() bool
-	ShellCommandNames() []string
-	AllowedServices() []string
-	AllowedContainers() []string
-	AllowedUnits() []string
-	RunNamedCommand(ctx context.Context, name string) (string, error)
-	SystemctlStatus(ctx context.Context, service string) (string, error)
-	SystemctlAction(ctx context.Context, action string, service string) (string, error)
-	DockerPS(ctx context.Context) (string, error)
-	DockerLogs(ctx context.Context, container string, lines int) (string, error)
-	JournalTail(ctx context.Context, unit string, lines int) (string, error)
-}
-
-type AgentStats struct {
-	InMemoryMessages int `json:"in_memory_messages"`
-	StoredSessions   int `json:"stored_sessions"`
-	StoredMessages   int `json:"stored_messages"`
+type AgentOptions struct {
+	SystemPrompt     string
+	Tools            *ToolRegistry
+	LLM              ChatProvider
+	Sessions         *SessionStore
+	HistoryMessages  int
+	ContextTokens    int
+	SummarizeAfter   int
+	MemorySharing    MemorySharing
+	Identities       IdentityResolver
+	Access           *AccessPolicy
+	ConfirmTimeout   time.Duration
+	Audit            AuditLog
+	Usage            *UsageTracker
+	UserRateLimit    RateLimit
+	ChannelRateLimit RateLimit
+	MaxConcurrentLLM int
+	Schedules        *ScheduleStore
+	Logger           *slog.Logger
 }
 
 type Agent struct {
-	mu           sync.Mutex
-	systemPrompt string
-	browser      BrowserTool
-	webFetch     WebFetchTool
-	server       ServerTool
-	llm          ChatProvider
-	sessions     *SessionStore
-	memory       []Message
-	maxMemory    int
+	mu             sync.Mutex
+	logger         *slog.Logger
+	settings       atomic.Pointer[agentSettings]
+	tools          *ToolRegistry
+	llm            ChatProvider
+	sessions       *SessionStore
+	window         ContextWindow
+	summarizeAfter int
+	compacting     map[string]context.CancelFunc
+	compactFailed  map[string]time.Time
+	sharing        MemorySharing
+	identities     IdentityResolver
+	confirmations  *confirmationStore
+	audit          AuditLog
+	usage          *UsageTracker
+	queue          *sessionQueue
+	llmSlots       semaphore
+	schedules      *ScheduleStore
+	memory         []memoryEntry
+	maxMemory      int
+	counters       agentCounters
 }
 
-func NewAgent(systemPrompt string, browser BrowserTool, webFetch WebFetchTool, server ServerTool, llm ChatProvider, sessions *SessionStore) *Agent {
-	if strings.TrimSpace(systemPrompt) == "" {
-		systemPrompt = "You are ClawKangsar, a professional assistant running on a Raspberry Pi. Keep responses concise and use your browser tool only when real-time data is needed."
-	}
+// agentCounters are the running totals reported by Stats.
+type agentCounters struct {
+	mu        sync.Mutex
+	messages  map[string]int
+	toolCalls map[string]map[string]int
+}
 
-	return &Agent{
-		systemPrompt: systemPrompt,
-		browser:      browser,
-		webFetch:     webFetch,
-		server:       server,
-		llm:          llm,
-		sessions:     sessions,
-		memory:       make([]Message, 0, 64),
-		maxMemory:    128,
-	}
+func NewAgent(opts AgentOptions) *Agent {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	tools := opts.Tools
+	if tools == nil {
+		tools = NewToolRegistry()
+	}
+
+	sharing := opts.MemorySharing
+	if sharing == "" {
+		sharing = MemoryIsolated
+	}
+
+	historyMessages := opts.HistoryMessages
+	if historyMessages <= 0 {
+		historyMessages = 16
+	}
+
+	window := ContextWindow{
+		MaxMessages: historyMessages,
+		MaxTokens:   opts.ContextTokens,
+	}
+	if estimator, ok := opts.LLM.(TokenEstimator); ok {
+		window.Estimator = estimator
+	}
+
+	agent := &Agent{
+		logger:         logger,
+		tools:          tools,
+		llm:            opts.LLM,
+		sessions:       opts.Sessions,
+		window:         window,
+		summarizeAfter: opts.SummarizeAfter,
+		compacting:     make(map[string]context.CancelFunc),
+		compactFailed:  make(map[string]time.Time),
+		sharing:        sharing,
+		identities:     opts.Identities,
+		confirmations:  newConfirmationStore(opts.ConfirmTimeout),
+		audit:          opts.Audit,
+		usage:          opts.Usage,
+		queue:          newSessionQueue(),
+		llmSlots:       newSemaphore(opts.MaxConcurrentLLM),
+		schedules:      opts.Schedules,
+		memory:         make([]memoryEntry, 0, 64),
+		maxMemory:      128,
+		counters: agentCounters{
+			messages:  make(map[string]int),
+			toolCalls: make(map[string]map[string]int),
+		},
+	}
+	agent.Reload(ReloadOptions{
+		SystemPrompt:     opts.SystemPrompt,
+		Access:           opts.Access,
+		UserRateLimit:    opts.UserRateLimit,
+		ChannelRateLimit: opts.ChannelRateLimit,
+	})
+	if opts.Schedules != nil {
+		if err := tools.Register(&scheduleTool{agent: agent}); err != nil {
+			logger.Warn("failed to register schedule tool", "error", err)
+		}
+	}
+	return agent
 }
 
 func (a *Agent) Process(ctx context.Context, msg Message) (string, error) {
+	return a.ProcessStream(ctx, msg, nil)
+}
+
+// ProcessStream is Process with progress reporting: LLM replies are passed to
+// onPartial while they stream. Commands and non-streaming providers only
+// return the final reply.
+func (a *Agent) ProcessStream(ctx context.Context, msg Message, onPartial PartialFunc) (string, error) {
 	msg.Text = strings.TrimSpace(msg.Text)
 	if msg.Timestamp.IsZero() {
 		msg.Timestamp = time.Now()
@@ -77,124 +159,120 @@ func (a *Agent) Process(ctx context.Context, msg Message) (string, error) {
 	if msg.Text == "" {
 		return "", nil
 	}
+	a.countMessage(msg.Channel)
 
-	sessionKey := messageSessionKey(msg)
-	memorySize := a.remember(msg)
-	lower := strings.ToLower(msg.Text)
-
-	if strings.HasPrefix(lower, "/status") {
-		stats := a.Stats()
-		return fmt.Sprintf("ClawKangsar status: memory=%d sessions=%d stored_messages=%d",
-			stats.InMemoryMessages,
-			stats.StoredSessions,
-			stats.StoredMessages,
-		), nil
-	}
-
-	if strings.HasPrefix(lower, "/fetch ") && a.webFetch != nil {
-		target := strings.TrimSpace(msg.Text[len("/fetch "):])
-		if target == "" {
-			return "Provide a URL after /fetch.", nil
-		}
-		text, err := a.webFetch.Fetch(ctx, target)
-		if err != nil {
-			return "", err
-		}
-		return truncate(text, 1200), nil
+	role := a.roleFor(msg)
+	if role == RoleNone {
+		a.logger.Warn("message rejected by access policy", "channel", msg.Channel, "user_id", msg.UserID)
+		return "Not permitted.", nil
 	}
-
-	if strings.HasPrefix(lower, "/browse ") {
-		target := strings.TrimSpace(msg.Text[len("/browse "):])
-		if target == "" {
-			return "Provide a URL after /browse.", nil
-		}
-
-		if a.webFetch != nil {
-			// Try lightweight HTTP fetch first to avoid booting Chromium on Pi.
-			text, err := a.webFetch.Fetch(ctx, target)
-			if err == nil && strings.TrimSpace(text) != "" {
-				return truncate(text, 1200), nil
-			}
-		}
-
-		if a.browser == nil {
-			return "Browser tool unavailable.", nil
-		}
-
-		text, err := a.browser.Browse(ctx, target)
-		if err != nil {
-			return "", fmt.Errorf("browse failed: %w", err)
-		}
-		return truncate(text, 1200), nil
+	if limited, reply := a.checkRateLimits(msg); limited {
+		return reply, nil
 	}
+	return a.handle(ctx, msg, role, onPartial)
+}
 
-	if strings.HasPrefix(lower, "/cmd ") && a.server != nil {
-		name := strings.TrimSpace(msg.Text[len("/cmd "):])
-		if name == "" {
-			return "Usage: /cmd <name>.", nil
-		}
-		text, err := a.server.RunNamedCommand(ctx, name)
-		if err != nil {
-			return "", err
-		}
-		return truncate(text, 2000), nil
+// handle answers a message that has passed the access and rate checks.
+func (a *Agent) handle(ctx context.Context, msg Message, role Role, onPartial PartialFunc) (string, error) {
+	// Messages of one chat are answered one at a time and in order, so a
+	// reply never races the history of the message before it.
+	sessionKey := a.sessionKeyFor(msg)
+	done, err := a.queue.acquire(ctx, sessionKey)
+	if err != nil {
+		return "", err
 	}
+	defer done()
 
-	if strings.HasPrefix(lower, "/service ") && a.server != nil {
-		text, err := a.handleServiceCommand(ctx, msg.Text)
-		if err != nil {
-			return "", err
-		}
-		return truncate(text, 2000), nil
-	}
+	memorySize := a.remember(msg, sessionKey)
+	lower := strings.ToLower(msg.Text)
+	c := caller{msg: msg, sessionKey: sessionKey, role: role, onPartial: onPartial}
 
-	if strings.HasPrefix(lower, "/docker ") && a.server != nil {
-		text, err := a.handleDockerCommand(ctx, msg.Text)
-		if err != nil {
-			return "", err
-		}
-		return truncate(text, 2000), nil
+	if fields := strings.Fields(lower); a.isBuiltinCommand(fields[0]) && !a.permits(c, a.commandRole(fields[0], builtinCommandRole(fields[0]))) {
+		return "Not permitted.", nil
 	}
 
-	if strings.HasPrefix(lower, "/logs ") && a.server != nil {
-		text, err := a.handleLogsCommand(ctx, msg.Text)
+	if strings.HasPrefix(lower, "/status") {
+		stats := a.Stats()
+		reply := fmt.Sprintf("ClawKangsar status: memory=%d sessions=%d stored_messages=%d role=%s",
+			stats.InMemoryMessages,
+			stats.StoredSessions,
+			stats.StoredMessages,
+			role,
+		)
+		if stats.Usage != nil {
+			mine := a.usage.UserTotals(a.usageUser(msg), msg.Timestamp)
+			reply += fmt.Sprintf(" tokens_today=%d cost_today=%.4f my_tokens_today=%d",
+				stats.Usage.Total.Tokens(),
+				stats.Usage.Total.Cost,
+				mine.Tokens(),
+			)
+		}
+		return reply, nil
+	}
+
+	switch lower {
+	case "/reset":
+		return a.resetSession(c)
+	case "/forget":
+		return a.forgetUser(c)
+	case "/compact":
+		return a.handleCompactCommand(ctx, sessionKey), nil
+	}
+
+	if fields := strings.Fields(lower); fields[0] == "/link" || fields[0] == "/unlink" {
+		return a.handleLinkCommand(c, strings.Fields(msg.Text))
+	} else if fields[0] == ConfirmCommand {
+		reply, err := a.handleConfirmCommand(ctx, c, fields)
+		return truncate(reply, 2000), err
+	} else if fields[0] == "/audit" {
+		reply, err := a.handleAuditCommand(fields)
+		return truncate(reply, 2000), err
+	} else if fields[0] == "/schedule" || fields[0] == "/schedules" || fields[0] == "/unschedule" {
+		reply, err := a.handleScheduleCommand(c, strings.Fields(msg.Text))
+		return truncate(reply, 2000), err
+	}
+
+	if reply, handled, err := a.handleCommand(ctx, c); handled {
 		if err != nil {
 			return "", err
 		}
-		return truncate(text, 2000), nil
+		return truncate(reply, 2000), nil
 	}
 
 	if a.llm != nil {
-		reply, err := a.replyWithLLM(ctx, sessionKey)
+		if err := a.usage.CheckBudget(a.usageUser(msg), msg.Timestamp); err != nil {
+			a.logger.Warn("llm call blocked by budget", "channel", msg.Channel, "user_id", msg.UserID, "error", err)
+			return fmt.Sprintf("Not answering: %s. Commands still work.", err), nil
+		}
+		reply, err := a.replyWithLLM(ctx, c)
 		if err != nil {
 			return "", err
 		}
 		a.rememberAssistant(msg, sessionKey, reply)
+		a.maybeCompact(sessionKey)
 		return truncate(reply, 2000), nil
 	}
 
 	return fmt.Sprintf("ClawKangsar ready. Channel=%s memory=%d. Configure llm.enabled=true for real replies.", msg.Channel, memorySize), nil
 }
 
-func (a *Agent) remember(msg Message) int {
+func (a *Agent) remember(msg Message, sessionKey string) int {
+	currentSize := a.appendMemory(sessionKey, msg)
+	if a.sessions != nil {
+		_ = a.sessions.AddMessage(sessionKey, msg)
+	}
+	return currentSize
+}
+
+func (a *Agent) appendMemory(sessionKey string, msg Message) int {
 	a.mu.Lock()
+	defer a.mu.Unlock()
 
-	a.memory = append(a.memory, msg)
+	a.memory = append(a.memory, memoryEntry{sessionKey: sessionKey, message: msg})
 	if len(a.memory) > a.maxMemory {
 		a.memory = a.memory[len(a.memory)-a.maxMemory:]
 	}
-	currentSize := len(a.memory)
-	a.mu.Unlock()
-
-	if a.sessions != nil {
-		sessionKey := messageSessionKey(msg)
-		_ = a.sessions.AddMessage(sessionKey, msg)
-		if sessionKey != "global" {
-			_ = a.sessions.AddMessage("global", msg)
-		}
-	}
-
-	return currentSize
+	return len(a.memory)
 }
 
 func (a *Agent) rememberAssistant(source Message, sessionKey string, reply string) {
@@ -211,31 +289,26 @@ func (a *Agent) rememberAssistant(source Message, sessionKey string, reply strin
 		Timestamp: time.Now(),
 	}
 
-	a.mu.Lock()
-	a.memory = append(a.memory, assistantMsg)
-	if len(a.memory) > a.maxMemory {
-		a.memory = a.memory[len(a.memory)-a.maxMemory:]
-	}
-	a.mu.Unlock()
-
+	a.appendMemory(sessionKey, assistantMsg)
 	if a.sessions != nil {
 		_ = a.sessions.AddMessage(sessionKey, assistantMsg)
-		if sessionKey != "global" {
-			_ = a.sessions.AddMessage("global", assistantMsg)
-		}
 	}
 }
 
-func (a *Agent) replyWithLLM(ctx context.Context, sessionKey string) (string, error) {
+func (a *Agent) replyWithLLM(ctx context.Context, c caller) (string, error) {
 	if a.llm == nil {
 		return "", fmt.Errorf("llm provider not configured")
 	}
 
-	messages := a.buildLLMMessages(sessionKey)
-	tools := a.availableTools()
+	system := a.systemMessages(c.sessionKey)
+	history := a.historyMessages(c.sessionKey)
+	tools := a.availableTools(c.role)
+	c.origin = AuditOriginLLM
+	inflight := make([]LLMMessage, 0, 8)
 
 	for i := 0; i < 4; i++ {
-		response, err := a.llm.Complete(ctx, messages, tools)
+		messages := a.window.Build(system, history, inflight)
+		response, err := a.complete(ctx, c, messages, tools)
 		if err != nil {
 			return "", err
 		}
@@ -243,44 +316,89 @@ func (a *Agent) replyWithLLM(ctx context.Context, sessionKey string) (string, er
 			return strings.TrimSpace(response.Content), nil
 		}
 
-		messages = append(messages, LLMMessage{
+		inflight = append(inflight, LLMMessage{
 			Role:      "assistant",
 			Content:   strings.TrimSpace(response.Content),
 			ToolCalls: response.ToolCalls,
 		})
 
+		var confirmations []string
 		for _, call := range response.ToolCalls {
-			output := a.executeToolCall(ctx, call)
-			messages = append(messages, LLMMessage{
+			output, pending := a.executeToolCall(ctx, c, call)
+			if pending {
+				confirmations = append(confirmations, output)
+			}
+			inflight = append(inflight, LLMMessage{
 				Role:       "tool",
 				Content:    output,
 				ToolCallID: call.ID,
 			})
 		}
+		// The model may paraphrase or drop a confirmation code, so the
+		// user gets it verbatim and the loop ends until they confirm.
+		if len(confirmations) > 0 {
+			return confirmationReply(response.Content, confirmations), nil
+		}
 	}
 
 	return "", fmt.Errorf("llm exceeded tool-call iteration limit")
 }
 
-func (a *Agent) buildLLMMessages(sessionKey string) []LLMMessage {
-	messages := make([]LLMMessage, 0, 17)
-	if strings.TrimSpace(a.systemPrompt) != "" {
+func (a *Agent) complete(ctx context.Context, c caller, messages []LLMMessage, tools []ToolDefinition) (LLMResponse, error) {
+	release, err := a.llmSlots.acquire(ctx)
+	if err != nil {
+		return LLMResponse{}, err
+	}
+	defer release()
+
+	var response LLMResponse
+	if streamer, ok := a.llm.(StreamingProvider); ok && c.onPartial != nil {
+		response, err = streamer.CompleteStream(ctx, messages, tools, c.onPartial)
+	} else {
+		response, err = a.llm.Complete(ctx, messages, tools)
+	}
+	if err == nil {
+		a.recordUsage(a.usageUser(c.msg), c.sessionKey, response)
+	}
+	return response, err
+}
+
+func (a *Agent) systemMessages(sessionKey string) []LLMMessage {
+	messages := make([]LLMMessage, 0, 2)
+	if systemPrompt := a.settings.Load().systemPrompt; strings.TrimSpace(systemPrompt) != "" {
+		messages = append(messages, LLMMessage{
+			Role:    "system",
+			Content: systemPrompt,
+		})
+	}
+	if summary, _ := a.sessions.Summary(sessionKey); summary != "" {
 		messages = append(messages, LLMMessage{
 			Role:    "system",
-			Content: a.systemPrompt,
+			Content: "Summary of the earlier conversation:\n" + summary,
 		})
 	}
+	return messages
+}
 
+func (a *Agent) historyMessages(sessionKey string) []LLMMessage {
 	var history []Message
 	if a.sessions != nil {
 		history = a.sessions.History(sessionKey)
+		if _, summarized := a.sessions.Summary(sessionKey); summarized > 0 && summarized <= len(history) {
+			history = history[summarized:]
+		}
 	} else {
 		a.mu.Lock()
-		history = make([]Message, len(a.memory))
-		copy(history, a.memory)
+		history = make([]Message, 0, len(a.memory))
+		for _, entry := range a.memory {
+			if entry.sessionKey == sessionKey {
+				history = append(history, entry.message)
+			}
+		}
 		a.mu.Unlock()
 	}
 
+	messages := make([]LLMMessage, 0, len(history))
 	for _, item := range history {
 		text := strings.TrimSpace(item.Text)
 		if text == "" {
@@ -306,257 +424,108 @@ func (a *Agent) buildLLMMessages(sessionKey string) []LLMMessage {
 	return messages
 }
 
-func (a *Agent) availableTools() []ToolDefinition {
-	tools := make([]ToolDefinition, 0, 8)
-	if a.webFetch != nil {
-		tools = append(tools, ToolDefinition{
-			Name:        "web_fetch",
-			Description: "Fetch a URL using a lightweight HTTP request and return readable text content. Prefer this for real-time data.",
-			Parameters: map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"url": map[string]any{
-						"type":        "string",
-						"description": "HTTP or HTTPS URL to fetch",
-					},
-				},
-				"required": []string{"url"},
-			},
-		})
+// availableTools lists only the tools the caller's role may use, so the model
+// never sees the others.
+func (a *Agent) availableTools(role Role) []ToolDefinition {
+	tools := a.tools.Tools()
+	definitions := make([]ToolDefinition, 0, len(tools))
+	for _, tool := range tools {
+		if role >= a.toolRole(tool) {
+			definitions = append(definitions, tool.Definition())
+		}
 	}
-	if a.browser != nil {
-		tools = append(tools, ToolDefinition{
-			Name:        "browser_browse",
-			Description: "Open a URL in the headless browser and return visible page text. Use only when lightweight fetch is insufficient.",
-			Parameters: map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"url": map[string]any{
-						"type":        "string",
-						"description": "HTTP or HTTPS URL to browse",
-					},
-				},
-				"required": []string{"url"},
-			},
-		})
+	return definitions
+}
+
+// executeToolCall runs a tool requested by the LLM. pending reports that the
+// call is waiting for confirmation and text holds the instructions.
+func (a *Agent) executeToolCall(ctx context.Context, c caller, call ToolCall) (text string, pending bool) {
+	text, status, err := a.runToolStatus(ctx, c, call)
+	if err != nil {
+		return "tool error: " + err.Error(), false
 	}
-	if a.server != nil {
-		if a.server.ShellAvailable() {
-			commands := a.server.ShellCommandNames()
-			tools = append(tools, ToolDefinition{
-				Name:        "shell_command",
-				Description: "Run one pre-approved named shell command alias. Allowed names: " + strings.Join(commands, ", "),
-				Parameters: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"name": map[string]any{
-							"type":        "string",
-							"description": "Approved command alias to run",
-						},
-					},
-					"required": []string{"name"},
-				},
-			})
-		}
-		if a.server.SystemctlAvailable() {
-			services := a.server.AllowedServices()
-			tools = append(tools, ToolDefinition{
-				Name:        "systemctl_status",
-				Description: "Get concise systemd service status for one allow-listed service. Allowed services: " + strings.Join(services, ", "),
-				Parameters: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"service": map[string]any{
-							"type":        "string",
-							"description": "Allow-listed systemd service name",
-						},
-					},
-					"required": []string{"service"},
-				},
-			})
-			tools = append(tools, ToolDefinition{
-				Name:        "systemctl_action",
-				Description: "Run start, stop, or restart on one allow-listed service, then return the updated status.",
-				Parameters: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"service": map[string]any{
-							"type":        "string",
-							"description": "Allow-listed systemd service name",
-						},
-						"action": map[string]any{
-							"type":        "string",
-							"description": "One of: start, stop, restart",
-						},
-					},
-					"required": []string{"service", "action"},
-				},
-			})
-		}
-		if a.server.DockerAvailable() {
-			tools = append(tools, ToolDefinition{
-				Name:        "docker_ps",
-				Description: "List Docker containers and their status if Docker tools are enabled.",
-				Parameters: map[string]any{
-					"type":       "object",
-					"properties": map[string]any{},
-				},
-			})
-		}
-		if containers := a.server.AllowedContainers(); len(containers) > 0 {
-			tools = append(tools, ToolDefinition{
-				Name:        "docker_logs",
-				Description: "Read recent logs for one allow-listed Docker container. Allowed containers: " + strings.Join(containers, ", "),
-				Parameters: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"container": map[string]any{
-							"type":        "string",
-							"description": "Allow-listed Docker container name",
-						},
-						"lines": map[string]any{
-							"type":        "integer",
-							"description": "Optional number of log lines to return",
-						},
-					},
-					"required": []string{"container"},
-				},
-			})
-		}
-		if a.server.JournalAvailable() {
-			units := a.server.AllowedUnits()
-			tools = append(tools, ToolDefinition{
-				Name:        "journal_tail",
-				Description: "Read recent journal logs for one allow-listed systemd unit. Allowed units: " + strings.Join(units, ", "),
-				Parameters: map[string]any{
-					"type": "object",
-					"properties": map[string]any{
-						"unit": map[string]any{
-							"type":        "string",
-							"description": "Allow-listed systemd unit name",
-						},
-						"lines": map[string]any{
-							"type":        "integer",
-							"description": "Optional number of log lines to return",
-						},
-					},
-					"required": []string{"unit"},
-				},
-			})
-		}
+	return truncate(text, 4000), status == AuditStatusPending
+}
+
+// confirmationReply puts the confirmation instructions after whatever the
+// model said, shortening the latter so the codes survive the reply limit.
+func confirmationReply(content string, confirmations []string) string {
+	reply := strings.Join(confirmations, "\n\n")
+	if content = strings.TrimSpace(content); content != "" {
+		reply = truncate(content, 1000) + "\n\n" + reply
 	}
-	return tools
+	return reply
 }
 
-func (a *Agent) executeToolCall(ctx context.Context, call ToolCall) string {
-	switch call.Name {
-	case "web_fetch":
-		url := getStringArgument(call.Arguments, "url")
-		if url == "" {
-			return "tool error: missing required string field `url`"
-		}
-		if a.webFetch == nil {
-			return "tool error: web_fetch is unavailable"
-		}
-		text, err := a.webFetch.Fetch(ctx, url)
-		if err != nil {
-			return "tool error: " + err.Error()
-		}
-		return truncate(text, 4000)
-	case "browser_browse":
-		url := getStringArgument(call.Arguments, "url")
-		if url == "" {
-			return "tool error: missing required string field `url`"
-		}
-		if a.browser == nil {
-			return "tool error: browser_browse is unavailable"
-		}
-		text, err := a.browser.Browse(ctx, url)
-		if err != nil {
-			return "tool error: " + err.Error()
-		}
-		return truncate(text, 4000)
-	case "shell_command":
-		if a.server == nil {
-			return "tool error: shell_command is unavailable"
-		}
-		name := getStringArgument(call.Arguments, "name")
-		if name == "" {
-			return "tool error: missing required string field `name`"
-		}
-		text, err := a.server.RunNamedCommand(ctx, name)
-		if err != nil {
-			return "tool error: " + err.Error()
-		}
-		return truncate(text, 4000)
-	case "systemctl_status":
-		if a.server == nil {
-			return "tool error: systemctl_status is unavailable"
-		}
-		service := getStringArgument(call.Arguments, "service")
-		if service == "" {
-			return "tool error: missing required string field `service`"
-		}
-		text, err := a.server.SystemctlStatus(ctx, service)
-		if err != nil {
-			return "tool error: " + err.Error()
-		}
-		return truncate(text, 4000)
-	case "systemctl_action":
-		if a.server == nil {
-			return "tool error: systemctl_action is unavailable"
-		}
-		service := getStringArgument(call.Arguments, "service")
-		action := getStringArgument(call.Arguments, "action")
-		if service == "" {
-			return "tool error: missing required string field `service`"
-		}
-		if action == "" {
-			return "tool error: missing required string field `action`"
-		}
-		text, err := a.server.SystemctlAction(ctx, action, service)
-		if err != nil {
-			return "tool error: " + err.Error()
-		}
-		return truncate(text, 4000)
-	case "docker_ps":
-		if a.server == nil {
-			return "tool error: docker_ps is unavailable"
-		}
-		text, err := a.server.DockerPS(ctx)
-		if err != nil {
-			return "tool error: " + err.Error()
-		}
-		return truncate(text, 4000)
-	case "docker_logs":
-		if a.server == nil {
-			return "tool error: docker_logs is unavailable"
-		}
-		container := getStringArgument(call.Arguments, "container")
-		if container == "" {
-			return "tool error: missing required string field `container`"
-		}
-		text, err := a.server.DockerLogs(ctx, container, getIntArgument(call.Arguments, "lines"))
-		if err != nil {
-			return "tool error: " + err.Error()
-		}
-		return truncate(text, 4000)
-	case "journal_tail":
-		if a.server == nil {
-			return "tool error: journal_tail is unavailable"
-		}
-		unit := getStringArgument(call.Arguments, "unit")
-		if unit == "" {
-			return "tool error: missing required string field `unit`"
-		}
-		text, err := a.server.JournalTail(ctx, unit, getIntArgument(call.Arguments, "lines"))
-		if err != nil {
-			return "tool error: " + err.Error()
+// runTool enforces availability, roles and confirmations, then executes the
+// call. Every attempt is written to the audit log.
+func (a *Agent) runTool(ctx context.Context, c caller, call ToolCall) (string, error) {
+	reply, _, err := a.runToolStatus(ctx, c, call)
+	return reply, err
+}
+
+// runToolStatus is runTool that also returns the audit status.
+func (a *Agent) runToolStatus(ctx context.Context, c caller, call ToolCall) (reply string, status string, err error) {
+	started := time.Now()
+	status = AuditStatusOK
+	// Unknown names come from the LLM and are counted together.
+	counted := "unknown"
+	defer func() {
+		if err != nil && status == AuditStatusOK {
+			status = AuditStatusError
+		}
+		a.recordAudit(c, call, status, err, time.Since(started))
+		a.countToolCall(counted, status)
+	}()
+
+	tool, ok := a.tools.Lookup(call.Name)
+	if !ok {
+		return "", status, fmt.Errorf("unknown tool `%s`", call.Name)
+	}
+	counted = call.Name
+	if !tool.Available() {
+		return "", status, fmt.Errorf("%s is unavailable", call.Name)
+	}
+	if required := a.toolRole(tool); !a.permits(c, required) {
+		status = AuditStatusDenied
+		a.logger.Warn("tool call denied", "tool", call.Name, "channel", c.msg.Channel, "user_id", c.msg.UserID, "role", c.role, "required", required)
+		return "", status, fmt.Errorf("%s requires the %s role", call.Name, required)
+	}
+	if confirmable, ok := tool.(Confirmable); ok && !c.confirmed {
+		if summary, required := confirmable.ConfirmationRequired(call.Arguments); required {
+			status = AuditStatusPending
+			reply, err = a.requestConfirmation(c, call, summary)
+			return reply, status, err
 		}
-		return truncate(text, 4000)
-	default:
-		return "tool error: unknown tool `" + call.Name + "`"
 	}
+	reply, err = tool.Execute(withCaller(ctx, c), call.Arguments)
+	return reply, status, err
+}
+
+// handleCommand dispatches registry slash commands. handled is false when the
+// text is not a registered command and should go to the LLM instead.
+func (a *Agent) handleCommand(ctx context.Context, c caller) (string, bool, error) {
+	fields := strings.Fields(c.msg.Text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", false, nil
+	}
+
+	cmd, ok := a.tools.Command(fields[0])
+	if !ok {
+		return "", false, nil
+	}
+	if !a.permits(c, a.commandRole(cmd.Name, cmd.Role)) {
+		return "Not permitted.", true, nil
+	}
+
+	call, ok := cmd.Resolve(fields[1:])
+	if !ok {
+		return cmd.Usage, true, nil
+	}
+	c.origin = AuditOriginCommand
+
+	reply, err := a.runTool(ctx, c, call)
+	return reply, true, err
 }
 
 func (a *Agent) Stats() AgentStats {
@@ -571,112 +540,69 @@ func (a *Agent) Stats() AgentStats {
 		stats.StoredSessions = a.sessions.SessionCount()
 		stats.StoredMessages = a.sessions.TotalMessageCount()
 	}
-	return stats
-}
-
-func messageSessionKey(msg Message) string {
-	if strings.TrimSpace(msg.Channel) != "" && strings.TrimSpace(msg.ChatID) != "" {
-		return msg.Channel + ":" + msg.ChatID
-	}
-	if strings.TrimSpace(msg.UserID) != "" {
-		return "user:" + strings.TrimSpace(msg.UserID)
+	if source, ok := a.llm.(ProviderStatsSource); ok {
+		stats.LLMProviders = source.ProviderStats()
 	}
-	return "global"
-}
-
-func truncate(text string, max int) string {
-	if len(text) <= max {
-		return text
+	if a.usage != nil {
+		// Stats are served without authentication, so the breakdown by
+		// user and session, which names chat accounts, is left out.
+		summary := a.usage.Summary("")
+		summary.ByUser, summary.BySession = nil, nil
+		stats.Usage = &summary
 	}
-	return text[:max] + "..."
-}
 
-func (a *Agent) handleServiceCommand(ctx context.Context, text string) (string, error) {
-	fields := strings.Fields(text)
-	if len(fields) < 3 {
-		return "Usage: /service status <name> or /service <start|stop|restart> <name>.", nil
+	a.counters.mu.Lock()
+	stats.Messages = make(map[string]int, len(a.counters.messages))
+	for channel, count := range a.counters.messages {
+		stats.Messages[channel] = count
 	}
-
-	action := strings.ToLower(strings.TrimSpace(fields[1]))
-	service := strings.TrimSpace(fields[2])
-
-	switch action {
-	case "status":
-		return a.server.SystemctlStatus(ctx, service)
-	case "start", "stop", "restart":
-		return a.server.SystemctlAction(ctx, action, service)
-	default:
-		return "Usage: /service status <name> or /service <start|stop|restart> <name>.", nil
+	stats.ToolCalls = make(map[string]map[string]int, len(a.counters.toolCalls))
+	for name, outcomes := range a.counters.toolCalls {
+		stats.ToolCalls[name] = make(map[string]int, len(outcomes))
+		for outcome, count := range outcomes {
+			stats.ToolCalls[name][outcome] = count
+		}
 	}
+	a.counters.mu.Unlock()
+	return stats
 }
 
-func (a *Agent) handleDockerCommand(ctx context.Context, text string) (string, error) {
-	fields := strings.Fields(text)
-	if len(fields) < 2 {
-		return "Usage: /docker ps or /docker logs <container> [lines].", nil
-	}
-
-	action := strings.ToLower(strings.TrimSpace(fields[1]))
-	switch action {
-	case "ps":
-		return a.server.DockerPS(ctx)
-	case "logs":
-		if len(fields) < 3 {
-			return "Usage: /docker logs <container> [lines].", nil
-		}
-		lines := 0
-		if len(fields) >= 4 {
-			lines = parseOptionalInt(fields[3])
-		}
-		return a.server.DockerLogs(ctx, fields[2], lines)
-	default:
-		return "Usage: /docker ps or /docker logs <container> [lines].", nil
+func (a *Agent) countMessage(channel string) {
+	if channel = strings.TrimSpace(channel); channel == "" {
+		channel = "unknown"
 	}
+	a.counters.mu.Lock()
+	a.counters.messages[channel]++
+	a.counters.mu.Unlock()
 }
 
-func (a *Agent) handleLogsCommand(ctx context.Context, text string) (string, error) {
-	fields := strings.Fields(text)
-	if len(fields) < 2 {
-		return "Usage: /logs <unit> [lines].", nil
-	}
+func (a *Agent) countToolCall(name string, status string) {
+	a.counters.mu.Lock()
+	defer a.counters.mu.Unlock()
 
-	lines := 0
-	if len(fields) >= 3 {
-		lines = parseOptionalInt(fields[2])
+	outcomes, ok := a.counters.toolCalls[name]
+	if !ok {
+		outcomes = make(map[string]int, 2)
+		a.counters.toolCalls[name] = outcomes
 	}
-	return a.server.JournalTail(ctx, fields[1], lines)
+	outcomes[status]++
 }
 
-func getStringArgument(values map[string]any, key string) string {
-	raw, ok := values[key]
-	if !ok {
-		return ""
+func messageSessionKey(msg Message) string {
+	if strings.TrimSpace(msg.Channel) != "" && strings.TrimSpace(msg.ChatID) != "" {
+		return msg.Channel + ":" + msg.ChatID
 	}
-	text, ok := raw.(string)
-	if !ok {
-		return ""
+	if strings.TrimSpace(msg.UserID) != "" {
+		return "user:" + strings.TrimSpace(msg.UserID)
 	}
-	return strings.TrimSpace(text)
+	return "global"
 }
 
-func getIntArgument(values map[string]any, key string) int {
-	raw, ok := values[key]
-	if !ok {
-		return 0
-	}
-
-	switch value := raw.(type) {
-	case int:
-		return value
-	case int64:
-		return int(value)
-	case float64:
-		return int(value)
-	case string:
-		return parseOptionalInt(value)
-	default:
-		return 0
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
 	}
+	return text[:max] + "..."
 }
 
 func parseOptionalInt(raw string) int {
@@ -0,0 +1,299 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AuditOriginAPI      = "api"
+	AuditOriginCommand  = "command"
+	AuditOriginLLM      = "llm"
+	AuditOriginSchedule = "schedule"
+
+	AuditStatusOK      = "ok"
+	AuditStatusError   = "error"
+	AuditStatusDenied  = "denied"
+	AuditStatusPending = "pending_confirmation"
+	AuditStatusExpired = "expired"
+)
+
+// AuditEntry records one tool execution attempt.
+type AuditEntry struct {
+	Time       time.Time      `json:"time"`
+	Channel    string         `json:"channel"`
+	UserID     string         `json:"user_id"`
+	Session    string         `json:"session"`
+	Tool       string         `json:"tool"`
+	Arguments  map[string]any `json:"arguments,omitempty"`
+	Origin     string         `json:"origin"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+}
+
+// AuditLog is an append-only record of tool executions.
+type AuditLog interface {
+	Record(entry AuditEntry) error
+	// Recent returns up to limit entries, newest first.
+	Recent(limit int) ([]AuditEntry, error)
+}
+
+// AuditFile appends entries as JSON lines and rotates the file once it grows
+// past MaxBytes, keeping MaxFiles old generations (audit.jsonl.1, .2, ...).
+type AuditFile struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+func OpenAuditFile(path string, maxBytes int64, maxFiles int) (*AuditFile, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, errors.New("audit log path is required")
+	}
+	if maxFiles < 0 {
+		maxFiles = 0
+	}
+
+	audit := &AuditFile{
+		path:     path,
+		maxBytes: maxBytes,
+		maxFiles: maxFiles,
+	}
+	if err := audit.openLocked(); err != nil {
+		return nil, err
+	}
+	return audit, nil
+}
+
+func (f *AuditFile) Record(entry AuditEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry: %w", err)
+	}
+	payload = append(payload, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return errors.New("audit log is closed")
+	}
+	if f.maxBytes > 0 && f.size > 0 && f.size+int64(len(payload)) > f.maxBytes {
+		if err := f.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	written, err := f.file.Write(payload)
+	f.size += int64(written)
+	if err != nil {
+		return fmt.Errorf("write audit entry: %w", err)
+	}
+	return nil
+}
+
+func (f *AuditFile) Recent(limit int) ([]AuditEntry, error) {
+	if limit <= 0 {
+		return []AuditEntry{}, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries := make([]AuditEntry, 0, limit)
+	for generation := 0; generation <= f.maxFiles && len(entries) < limit; generation++ {
+		fileEntries, err := readAuditEntries(f.generationPath(generation))
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				break
+			}
+			return nil, err
+		}
+		for i := len(fileEntries) - 1; i >= 0 && len(entries) < limit; i-- {
+			entries = append(entries, fileEntries[i])
+		}
+	}
+	return entries, nil
+}
+
+func (f *AuditFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *AuditFile) openLocked() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return fmt.Errorf("create audit directory: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat audit log: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+func (f *AuditFile) rotateLocked() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("close audit log: %w", err)
+	}
+	f.file = nil
+
+	if f.maxFiles == 0 {
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("truncate audit log: %w", err)
+		}
+		return f.openLocked()
+	}
+
+	_ = os.Remove(f.generationPath(f.maxFiles))
+	for generation := f.maxFiles - 1; generation >= 0; generation-- {
+		if err := os.Rename(f.generationPath(generation), f.generationPath(generation+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("rotate audit log: %w", err)
+		}
+	}
+	return f.openLocked()
+}
+
+func (f *AuditFile) generationPath(generation int) string {
+	if generation == 0 {
+		return f.path
+	}
+	return f.path + "." + strconv.Itoa(generation)
+}
+
+func readAuditEntries(path string) ([]AuditEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]AuditEntry, 0, 64)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+func (a *Agent) recordAudit(c caller, call ToolCall, status string, err error, duration time.Duration) {
+	if a.audit == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Time:       time.Now(),
+		Channel:    c.msg.Channel,
+		UserID:     c.msg.UserID,
+		Session:    c.sessionKey,
+		Tool:       call.Name,
+		Arguments:  call.Arguments,
+		Origin:     c.origin,
+		Status:     status,
+		DurationMS: duration.Milliseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := a.audit.Record(entry); err != nil {
+		a.logger.Error("audit log write failed", "error", err, "tool", call.Name)
+	}
+}
+
+// recordCommand audits a privileged command that changes state without
+// running a tool. The command name takes the place of the tool.
+func (a *Agent) recordCommand(c caller, command string, arguments map[string]any, status string, err error) {
+	if c.origin == "" {
+		c.origin = AuditOriginCommand
+	}
+	if err != nil && status == AuditStatusOK {
+		status = AuditStatusError
+	}
+	a.recordAudit(c, ToolCall{Name: command, Arguments: arguments}, status, err, 0)
+}
+
+// RecentAudit exposes the newest audit entries for status endpoints.
+func (a *Agent) RecentAudit(limit int) ([]AuditEntry, error) {
+	if a.audit == nil {
+		return []AuditEntry{}, nil
+	}
+	return a.audit.Recent(limit)
+}
+
+func (a *Agent) handleAuditCommand(fields []string) (string, error) {
+	if a.audit == nil {
+		return "Audit logging is not enabled.", nil
+	}
+
+	limit := 10
+	if len(fields) > 1 {
+		limit = parseOptionalInt(fields[1])
+		if limit <= 0 {
+			return "Usage: /audit [count]", nil
+		}
+		if limit > 50 {
+			limit = 50
+		}
+	}
+
+	entries, err := a.audit.Recent(limit)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		return "No audit entries yet.", nil
+	}
+
+	var b strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%s %s %s:%s %s [%s] %dms",
+			entry.Time.Format("01-02 15:04:05"),
+			entry.Origin,
+			entry.Channel,
+			entry.UserID,
+			entry.Tool,
+			entry.Status,
+			entry.DurationMS,
+		)
+		if entry.Error != "" {
+			b.WriteString(" - " + truncate(entry.Error, 120))
+		}
+		b.WriteByte('\n')
+	}
+	return strings.TrimSpace(b.String()), nil
+}
@@ -0,0 +1,240 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const compactionPrompt = "You maintain a running summary of a chat between a user and the ClawKangsar assistant. " +
+	"Merge the previous summary with the new transcript into one concise summary. " +
+	"Keep facts, preferences, decisions and open tasks; drop small talk. Reply with the summary only."
+
+const (
+	// compactionChunkTokens bounds one summarization prompt when no
+	// llm.context_tokens budget is configured.
+	compactionChunkTokens    = 6000
+	compactionMinChunkTokens = 500
+	compactionChunkTimeout   = 2 * time.Minute
+	compactionRetryDelay     = 15 * time.Minute
+)
+
+var errNothingToCompact = errors.New("nothing to compact")
+
+// compactSession folds every stored message older than the context window
+// into the session's rolling summary and returns how many it folded.
+func (a *Agent) compactSession(ctx context.Context, sessionKey string) (int, error) {
+	if a.llm == nil {
+		return 0, fmt.Errorf("llm provider not configured")
+	}
+	if a.sessions == nil {
+		return 0, fmt.Errorf("session storage is disabled")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	a.mu.Lock()
+	if _, running := a.compacting[sessionKey]; running {
+		a.mu.Unlock()
+		return 0, fmt.Errorf("compaction already running")
+	}
+	a.compacting[sessionKey] = cancel
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.compacting, sessionKey)
+		a.mu.Unlock()
+	}()
+
+	// The generation is read first so a change that lands between it and
+	// the history read makes the summary stale rather than misaligned.
+	generation := a.sessions.Generation(sessionKey)
+	history := a.sessions.History(sessionKey)
+	summary, summarized := a.sessions.Summary(sessionKey)
+	until := len(history) - a.window.MaxMessages
+	if until <= summarized {
+		return 0, errNothingToCompact
+	}
+
+	// Long sessions are folded in one chunk at a time, each saved before
+	// the next, so no single prompt outgrows the model's context.
+	start := summarized
+	for start < until {
+		transcript, end := a.compactionChunk(history, start, until, summary)
+		next, err := a.summarizeChunk(ctx, sessionKey, summary, transcript)
+		if err != nil {
+			a.recordCompactionFailure(sessionKey, err)
+			return start - summarized, err
+		}
+		if err := a.sessions.SetSummary(sessionKey, next, end, generation); err != nil {
+			return start - summarized, err
+		}
+		summary = next
+		start = end
+	}
+
+	a.mu.Lock()
+	delete(a.compactFailed, sessionKey)
+	a.mu.Unlock()
+	return until - summarized, nil
+}
+
+// compactionChunk renders history[start:] as a transcript until it reaches
+// until or the chunk budget, and returns where the chunk ends. At least one
+// message is always taken; a message too large for the budget is clipped.
+func (a *Agent) compactionChunk(history []Message, start int, until int, summary string) (string, int) {
+	budget := compactionChunkTokens
+	if a.window.MaxTokens > 0 {
+		budget = a.window.MaxTokens
+	}
+	budget -= a.window.estimateAll([]LLMMessage{
+		{Role: "system", Content: compactionPrompt},
+		{Role: "user", Content: summary},
+	})
+	budget = max(budget, compactionMinChunkTokens)
+
+	var transcript strings.Builder
+	end := start
+	for end < until {
+		item := history[end]
+		text := strings.TrimSpace(item.Text)
+		if text == "" {
+			end++
+			continue
+		}
+		speaker := "user"
+		if item.Channel == "assistant" {
+			speaker = "assistant"
+		}
+		line := speaker + ": " + text + "\n"
+		cost := a.window.estimateAll([]LLMMessage{{Role: "user", Content: line}})
+		if cost > budget {
+			if transcript.Len() > 0 {
+				break
+			}
+			line = truncate(line, budget*4) + "\n"
+			cost = budget
+		}
+		transcript.WriteString(line)
+		budget -= cost
+		end++
+	}
+	return transcript.String(), end
+}
+
+// summarizeChunk merges one transcript chunk into the previous summary.
+func (a *Agent) summarizeChunk(ctx context.Context, sessionKey string, summary string, transcript string) (string, error) {
+	prompt := "Previous summary:\n"
+	if strings.TrimSpace(summary) == "" {
+		prompt += "(none)\n"
+	} else {
+		prompt += summary + "\n"
+	}
+	prompt += "\nNew transcript:\n" + transcript
+
+	if err := a.usage.CheckBudget(usageSystemUser, time.Now()); err != nil {
+		return "", err
+	}
+	release, err := a.llmSlots.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	chunkCtx, cancel := context.WithTimeout(ctx, compactionChunkTimeout)
+	defer cancel()
+	response, err := a.llm.Complete(chunkCtx, []LLMMessage{
+		{Role: "system", Content: compactionPrompt},
+		{Role: "user", Content: prompt},
+	}, nil)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return "", errStaleSummary
+		}
+		return "", fmt.Errorf("summarize session: %w", err)
+	}
+	a.recordUsage(usageSystemUser, sessionKey, response)
+	if strings.TrimSpace(response.Content) == "" {
+		return "", fmt.Errorf("summarize session: empty summary")
+	}
+	return response.Content, nil
+}
+
+// recordCompactionFailure holds off automatic compaction of the session for
+// compactionRetryDelay, so a failing request is not repeated on every reply.
+func (a *Agent) recordCompactionFailure(sessionKey string, err error) {
+	if errors.Is(err, errStaleSummary) {
+		return
+	}
+	a.mu.Lock()
+	a.compactFailed[sessionKey] = time.Now()
+	a.mu.Unlock()
+}
+
+// cancelCompaction stops in-flight compactions of the given sessions, or of
+// every session when none are given, after their messages were removed.
+func (a *Agent) cancelCompaction(sessionKeys ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(sessionKeys) == 0 {
+		for _, cancel := range a.compacting {
+			cancel()
+		}
+		return
+	}
+	for _, key := range sessionKeys {
+		if cancel, ok := a.compacting[key]; ok {
+			cancel()
+		}
+	}
+}
+
+// maybeCompact starts a background compaction once enough messages have
+// accumulated past the existing summary.
+func (a *Agent) maybeCompact(sessionKey string) {
+	if a.summarizeAfter <= 0 || a.sessions == nil || a.llm == nil {
+		return
+	}
+
+	a.mu.Lock()
+	failed, backingOff := a.compactFailed[sessionKey]
+	a.mu.Unlock()
+	if backingOff && time.Since(failed) < compactionRetryDelay {
+		return
+	}
+
+	_, summarized := a.sessions.Summary(sessionKey)
+	pending := len(a.sessions.History(sessionKey)) - summarized - a.window.MaxMessages
+	if pending < a.summarizeAfter {
+		return
+	}
+
+	go func() {
+		_, err := a.compactSession(context.Background(), sessionKey)
+		if errors.Is(err, errStaleSummary) {
+			a.logger.Info("discarded stale session summary", "session", sessionKey)
+			return
+		}
+		if err != nil && !errors.Is(err, errNothingToCompact) {
+			a.logger.Warn("session compaction failed", "session", sessionKey, "error", err)
+		}
+	}()
+}
+
+func (a *Agent) handleCompactCommand(ctx context.Context, sessionKey string) string {
+	count, err := a.compactSession(ctx, sessionKey)
+	if errors.Is(err, errNothingToCompact) {
+		return "Nothing to compact yet."
+	}
+	if errors.Is(err, errStaleSummary) {
+		return "The conversation changed while compacting; nothing was saved."
+	}
+	if err != nil {
+		return "Compaction failed: " + err.Error()
+	}
+	return fmt.Sprintf("Compacted %d older messages into the session summary.", count)
+}
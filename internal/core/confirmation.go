@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConfirmCommand redeems a pending action. Gateways may recognise
+// "/confirm <code>" in a reply and offer it as a button.
+const ConfirmCommand = "/confirm"
+
+// Confirmable is implemented by tools whose calls can require an explicit
+// confirmation before they run. summary describes the call to the caller.
+type Confirmable interface {
+	ConfirmationRequired(args map[string]any) (summary string, required bool)
+}
+
+type pendingAction struct {
+	account   string
+	requester caller
+	call      ToolCall
+	summary   string
+	timer     *time.Timer
+}
+
+// confirmationStore holds actions waiting for their requester to confirm
+// them with a one-time code. Each action expires on its own timer.
+type confirmationStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	pending map[string]*pendingAction
+}
+
+func newConfirmationStore(ttl time.Duration) *confirmationStore {
+	if ttl <= 0 {
+		ttl = 2 * time.Minute
+	}
+	return &confirmationStore{
+		ttl:     ttl,
+		pending: make(map[string]*pendingAction),
+	}
+}
+
+// requestConfirmation parks the call and tells the caller how to confirm it.
+func (a *Agent) requestConfirmation(c caller, call ToolCall, summary string) (string, error) {
+	c.onPartial = nil
+	action := &pendingAction{
+		account:   NormalizeAccount(c.msg.Channel, c.msg.UserID),
+		requester: c,
+		call:      call,
+		summary:   summary,
+	}
+	store := a.confirmations
+	store.mu.Lock()
+	// A code in use belongs to another pending action; overwriting it would
+	// drop that action without running or expiring it.
+	var code string
+	for {
+		var err error
+		code, err = randomDigits(6)
+		if err != nil {
+			store.mu.Unlock()
+			return "", err
+		}
+		if _, taken := store.pending[code]; !taken {
+			break
+		}
+	}
+	store.pending[code] = action
+	action.timer = time.AfterFunc(store.ttl, func() { a.expireConfirmation(code, action) })
+	store.mu.Unlock()
+
+	a.logger.Info("pending action created", "tool", call.Name, "summary", summary, "channel", c.msg.Channel, "user_id", c.msg.UserID)
+	return fmt.Sprintf("Confirmation required: %s.\nSend %s %s within %d seconds to proceed.",
+		summary, ConfirmCommand, code, int(store.ttl.Seconds())), nil
+}
+
+func (a *Agent) handleConfirmCommand(ctx context.Context, c caller, fields []string) (string, error) {
+	if len(fields) < 2 {
+		return "Usage: /confirm <code>", nil
+	}
+
+	code := strings.TrimSpace(fields[1])
+	store := a.confirmations
+
+	store.mu.Lock()
+	action, ok := store.pending[code]
+	if ok && action.account != NormalizeAccount(c.msg.Channel, c.msg.UserID) {
+		store.mu.Unlock()
+		a.logger.Warn("pending action confirmation rejected", "tool", action.call.Name, "channel", c.msg.Channel, "user_id", c.msg.UserID)
+		return "Only the requester can confirm this action.", nil
+	}
+	delete(store.pending, code)
+	store.mu.Unlock()
+
+	if !ok {
+		return "No pending action for that code. It may have expired.", nil
+	}
+	action.timer.Stop()
+
+	a.logger.Info("pending action confirmed", "tool", action.call.Name, "summary", action.summary, "channel", c.msg.Channel, "user_id", c.msg.UserID)
+	c.confirmed = true
+	c.origin = action.requester.origin
+	reply, err := a.runTool(ctx, c, action.call)
+	if err != nil {
+		return "Action failed: " + err.Error(), nil
+	}
+	return reply, nil
+}
+
+// expireConfirmation drops the action when its TTL passes, unless it was
+// confirmed first, and records the expiry in the log and audit trail.
+func (a *Agent) expireConfirmation(code string, action *pendingAction) {
+	store := a.confirmations
+	store.mu.Lock()
+	if store.pending[code] != action {
+		store.mu.Unlock()
+		return
+	}
+	delete(store.pending, code)
+	store.mu.Unlock()
+
+	a.logger.Warn("pending action expired", "tool", action.call.Name, "summary", action.summary, "account", action.account)
+	a.recordAudit(action.requester, action.call, AuditStatusExpired, nil, store.ttl)
+	a.countToolCall(action.call.Name, AuditStatusExpired)
+}
@@ -0,0 +1,108 @@
+package core
+
+import "encoding/json"
+
+// TokenEstimator approximates how many prompt tokens a message costs for a
+// given provider. Providers may implement it; otherwise a generic
+// characters-per-token heuristic is used.
+type TokenEstimator interface {
+	EstimateTokens(msg LLMMessage) int
+}
+
+// ContextWindow trims conversation history to a message count and an optional
+// token budget before it is sent to the LLM.
+type ContextWindow struct {
+	MaxMessages int
+	MaxTokens   int
+	Estimator   TokenEstimator
+}
+
+// Build returns system messages, as much recent history as fits, and the
+// in-flight messages of the current turn. System and in-flight messages are
+// never dropped, and an assistant tool call is never separated from its tool
+// results. The newest history message is always kept.
+func (w ContextWindow) Build(system []LLMMessage, history []LLMMessage, inflight []LLMMessage) []LLMMessage {
+	budget := -1
+	if w.MaxTokens > 0 {
+		budget = w.MaxTokens - w.estimateAll(system) - w.estimateAll(inflight)
+	}
+
+	groups := groupToolExchanges(history)
+	kept := 0
+	count := 0
+	for i := len(groups) - 1; i >= 0; i-- {
+		group := groups[i]
+		if kept > 0 {
+			if w.MaxMessages > 0 && count+len(group) > w.MaxMessages {
+				break
+			}
+			if budget >= 0 && w.estimateAll(group) > budget {
+				break
+			}
+		}
+		if budget >= 0 {
+			budget -= w.estimateAll(group)
+		}
+		count += len(group)
+		kept++
+	}
+
+	messages := make([]LLMMessage, 0, len(system)+count+len(inflight))
+	messages = append(messages, system...)
+	for _, group := range groups[len(groups)-kept:] {
+		messages = append(messages, group...)
+	}
+	messages = append(messages, inflight...)
+	return messages
+}
+
+func (w ContextWindow) estimateAll(messages []LLMMessage) int {
+	total := 0
+	for _, msg := range messages {
+		if w.Estimator != nil {
+			total += w.Estimator.EstimateTokens(msg)
+		} else {
+			total += EstimateTokens(msg, 4)
+		}
+	}
+	return total
+}
+
+// EstimateTokens is a rough tokenizer: content and tool-call arguments are
+// divided by charsPerToken, plus a small per-message overhead.
+func EstimateTokens(msg LLMMessage, charsPerToken float64) int {
+	if charsPerToken <= 0 {
+		charsPerToken = 4
+	}
+
+	chars := len(msg.Content)
+	for _, call := range msg.ToolCalls {
+		chars += len(call.Name)
+		if payload, err := json.Marshal(call.Arguments); err == nil {
+			chars += len(payload)
+		}
+	}
+	return int(float64(chars)/charsPerToken+0.5) + 4
+}
+
+// groupToolExchanges splits history into units that must be kept or dropped
+// together. Tool results without a preceding tool call are discarded.
+func groupToolExchanges(history []LLMMessage) [][]LLMMessage {
+	groups := make([][]LLMMessage, 0, len(history))
+	for i := 0; i < len(history); i++ {
+		msg := history[i]
+		if msg.Role == "tool" {
+			continue
+		}
+
+		group := []LLMMessage{msg}
+		if len(msg.ToolCalls) > 0 {
+			for i+1 < len(history) && history[i+1].Role == "tool" {
+				i++
+				group = append(group, history[i])
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
@@ -0,0 +1,213 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed five-field cron expression (minute, hour, day of
+// month, month, day of week) evaluated in local time. "@every <duration>"
+// and the @hourly, @daily, @weekly and @monthly shortcuts are accepted too.
+type CronSchedule struct {
+	expr   string
+	every  time.Duration
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+const allHours = 1<<24 - 1
+
+var cronShortcuts = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+var cronNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.Join(strings.Fields(strings.ToLower(expr)), " ")
+	schedule := &CronSchedule{expr: expr}
+
+	if rest, ok := strings.CutPrefix(expr, "@every "); ok {
+		every, err := time.ParseDuration(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every duration %q", rest)
+		}
+		if every < time.Minute {
+			return nil, fmt.Errorf("@every must be at least one minute")
+		}
+		schedule.every = every
+		return schedule, nil
+	}
+	if shortcut, ok := cronShortcuts[expr]; ok {
+		expr = shortcut
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have five fields: minute hour day month weekday", expr)
+	}
+
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if schedule.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if schedule.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if schedule.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if schedule.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// Both 0 and 7 mean Sunday.
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	// As in standard cron, a day field starting with "*" (such as "*/2")
+	// counts as unrestricted when combining the two day fields.
+	schedule.anyDom = strings.HasPrefix(fields[2], "*")
+	schedule.anyDow = strings.HasPrefix(fields[4], "*")
+	return schedule, nil
+}
+
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+// Next returns the first matching minute strictly after t. When clocks fall
+// back, a job with a restricted hour does not run again in the repeated hour;
+// jobs that run every hour keep running through it.
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every).Truncate(time.Minute)
+	}
+
+	t = t.Local().Truncate(time.Minute)
+	after := wallClock(t)
+	t = t.Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.Local))
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.Local))
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.Local))
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		if s.hour != allHours && !wallClock(t).After(after) {
+			t = forward(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, time.Local))
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// forward returns next, or the start of the following hour when next is not
+// after t. time.Date can map a wall time skipped by a DST change back onto t.
+func forward(t time.Time, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Hour - time.Duration(t.Minute())*time.Minute)
+}
+
+// wallClock drops the zone offset so times can be compared as read off a
+// local clock.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// dayMatches follows cron: when both day fields are restricted, either one
+// matching is enough.
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func parseCronField(field string, low int, high int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			parsed, err := strconv.Atoi(stepPart)
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = parsed
+		}
+
+		start, end := low, high
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = parseCronValue(from, low, high); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = parseCronValue(to, low, high); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = high
+			}
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(raw string, low int, high int) (int, error) {
+	value, ok := cronNames[raw]
+	if !ok {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", raw)
+		}
+		value = parsed
+	}
+	if value < low || value > high {
+		return 0, fmt.Errorf("value %d out of range %d-%d", value, low, high)
+	}
+	return value, nil
+}
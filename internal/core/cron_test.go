@@ -0,0 +1,120 @@
+package core
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+// useLocation makes name the local zone for the rest of the test.
+func useLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	saved := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = saved })
+	return loc
+}
+
+func TestCronNext(t *testing.T) {
+	utc := useLocation(t, "UTC")
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, utc)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"minute step", "*/15 * * * *", at(2026, 3, 2, 10, 7), at(2026, 3, 2, 10, 15)},
+		{"strictly after", "*/15 * * * *", at(2026, 3, 2, 10, 15), at(2026, 3, 2, 10, 30)},
+		{"range with step", "0 9-17/4 * * *", at(2026, 3, 2, 10, 0), at(2026, 3, 2, 13, 0)},
+		{"list", "5,45 8 * * *", at(2026, 3, 2, 8, 10), at(2026, 3, 2, 8, 45)},
+		{"weekday names", "0 8 * * mon-fri", at(2026, 3, 7, 9, 0), at(2026, 3, 9, 8, 0)},
+		{"sunday as 0", "0 0 * * 0", at(2026, 3, 7, 9, 0), at(2026, 3, 8, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", at(2026, 3, 7, 9, 0), at(2026, 3, 8, 0, 0)},
+		{"sunday in a range ending at 7", "0 0 * * 6-7", at(2026, 3, 7, 9, 0), at(2026, 3, 8, 0, 0)},
+		{"month names", "0 0 1 jan *", at(2026, 3, 1, 0, 0), at(2027, 1, 1, 0, 0)},
+		{"skips short months", "0 0 31 * *", at(2026, 4, 1, 0, 0), at(2026, 5, 31, 0, 0)},
+		{"leap day", "0 12 29 2 *", at(2026, 3, 1, 0, 0), at(2028, 2, 29, 12, 0)},
+		{"month rollover", "0 0 1 * *", at(2026, 12, 15, 0, 0), at(2027, 1, 1, 0, 0)},
+		{"both day fields restricted match either", "0 8 1 * 1", at(2026, 3, 1, 9, 0), at(2026, 3, 2, 8, 0)},
+		{"stepped day of month still needs the weekday", "0 8 */2 * 1", at(2026, 3, 1, 0, 0), at(2026, 3, 9, 8, 0)},
+		{"stepped weekday still needs the day of month", "0 8 13 * */7", at(2026, 3, 1, 0, 0), at(2026, 9, 13, 8, 0)},
+		{"shortcut", "@weekly", at(2026, 3, 4, 12, 0), at(2026, 3, 8, 0, 0)},
+		{"every", "@every 90m", at(2026, 3, 2, 10, 0), at(2026, 3, 2, 11, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextAcrossDST(t *testing.T) {
+	loc := useLocation(t, "America/New_York")
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+	// 01:30 happens twice on 2026-11-01: first in EDT, then in EST.
+	firstOneThirty := at(11, 1, 1, 30)
+	secondOneThirty := firstOneThirty.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"missing hour is skipped", "30 2 * * *", at(3, 8, 0, 0), at(3, 9, 2, 30)},
+		{"hourly job keeps running after the gap", "0 * * * *", at(3, 8, 1, 30), at(3, 8, 3, 0)},
+		{"repeated hour does not run twice", "30 1 * * *", firstOneThirty, at(11, 2, 1, 30)},
+		{"hourly job runs in the repeated hour", "*/30 * * * *", firstOneThirty, secondOneThirty.Add(-30 * time.Minute)},
+		{"daily job keeps its wall time", "0 9 * * *", at(11, 1, 0, 0), at(11, 1, 9, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@every 30s",
+		"@every soon",
+		"@fortnightly",
+	} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expr)
+		}
+	}
+}
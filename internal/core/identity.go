@@ -0,0 +1,397 @@
+package core
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Person lists the chat accounts that belong to one human, as configured.
+// Accounts use the form "<channel>:<user id>".
+type Person struct {
+	ID       string
+	Accounts []string
+}
+
+// A 6-digit code is only safe against guessing while attempts are scarce.
+// An account may fail maxLinkFailures times per code TTL and is held to
+// linkAttemptLimit; linkGlobalAttemptLimit is a backstop for guesses spread
+// over many accounts. Each account has at most one pending code.
+const maxLinkFailures = 5
+
+var (
+	linkAttemptLimit       = RateLimit{PerMinute: 3, Burst: 5}
+	linkGlobalAttemptLimit = RateLimit{PerMinute: 30, Burst: 30}
+)
+
+type pendingLink struct {
+	account string
+	expires time.Time
+}
+
+type linkFailures struct {
+	count int
+	since time.Time
+}
+
+type identityFile struct {
+	Links map[string]string `json:"links"`
+}
+
+// IdentityStore resolves chat accounts to people. Configured people are
+// read-only; links created with /link are persisted to a JSON file.
+type IdentityStore struct {
+	mu       sync.Mutex
+	path     string
+	codeTTL  time.Duration
+	static   map[string]string
+	linked   map[string]string
+	pending  map[string]pendingLink
+	failures map[string]linkFailures
+	attempts *rateLimiter
+	global   *rateLimiter
+}
+
+func NewIdentityStore(path string, people []Person, codeTTL time.Duration) (*IdentityStore, error) {
+	if codeTTL <= 0 {
+		codeTTL = 10 * time.Minute
+	}
+
+	store := &IdentityStore{
+		path:     strings.TrimSpace(path),
+		codeTTL:  codeTTL,
+		static:   make(map[string]string),
+		linked:   make(map[string]string),
+		pending:  make(map[string]pendingLink),
+		failures: make(map[string]linkFailures),
+		attempts: newRateLimiter(linkAttemptLimit),
+		global:   newRateLimiter(linkGlobalAttemptLimit),
+	}
+
+	for _, person := range people {
+		id := strings.TrimSpace(person.ID)
+		if id == "" {
+			return nil, errors.New("identity id is required")
+		}
+		for _, account := range person.Accounts {
+			channel, userID, ok := strings.Cut(account, ":")
+			if !ok {
+				return nil, fmt.Errorf("identity %s: account %q must be <channel>:<id>", id, account)
+			}
+			key := NormalizeAccount(channel, userID)
+			if owner, exists := store.static[key]; exists && owner != id {
+				return nil, fmt.Errorf("account %s belongs to both %s and %s", key, owner, id)
+			}
+			store.static[key] = id
+		}
+	}
+
+	if store.path != "" {
+		if err := store.load(); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// NormalizeAccount builds the "<channel>:<user id>" key used for identity
+// lookups. WhatsApp device suffixes are dropped and bare phone numbers are
+// expanded to user JIDs.
+func NormalizeAccount(channel string, userID string) string {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	userID = strings.TrimSpace(userID)
+	if channel == "whatsapp" {
+		userID = strings.TrimPrefix(userID, "+")
+		user, server, hasServer := strings.Cut(userID, "@")
+		if device := strings.IndexByte(user, ':'); device >= 0 {
+			user = user[:device]
+		}
+		if !hasServer {
+			server = "s.whatsapp.net"
+		}
+		userID = strings.ToLower(user + "@" + server)
+	}
+	return channel + ":" + userID
+}
+
+func (s *IdentityStore) ResolveIdentity(channel string, userID string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+
+	key := NormalizeAccount(channel, userID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookupLocked(key)
+}
+
+// Accounts returns every account that resolves to the given identity.
+func (s *IdentityStore) Accounts(identity string) []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accounts := make([]string, 0, 4)
+	for account, id := range s.static {
+		if id == identity {
+			accounts = append(accounts, account)
+		}
+	}
+	for account, id := range s.linked {
+		if _, isStatic := s.static[account]; !isStatic && id == identity {
+			accounts = append(accounts, account)
+		}
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// StartLink issues a one-time code that another account can redeem to join
+// the caller's identity. Any earlier code of the same account is revoked.
+func (s *IdentityStore) StartLink(channel string, userID string, now time.Time) (string, time.Duration, error) {
+	if strings.TrimSpace(userID) == "" {
+		return "", 0, errors.New("cannot link an anonymous sender")
+	}
+
+	code, err := randomDigits(6)
+	if err != nil {
+		return "", 0, err
+	}
+
+	account := NormalizeAccount(channel, userID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for existing, link := range s.pending {
+		if now.After(link.expires) || link.account == account {
+			delete(s.pending, existing)
+		}
+	}
+	s.pending[code] = pendingLink{
+		account: account,
+		expires: now.Add(s.codeTTL),
+	}
+	return code, s.codeTTL, nil
+}
+
+// CompleteLink redeems a code from a second account and links both accounts
+// to one identity. Attempts are rate limited per account and overall, and
+// failed attempts are capped per account.
+func (s *IdentityStore) CompleteLink(code string, channel string, userID string, now time.Time) (string, error) {
+	account := NormalizeAccount(channel, userID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failed := s.failures[account]
+	if now.Sub(failed.since) > s.codeTTL {
+		failed = linkFailures{}
+		delete(s.failures, account)
+	}
+	if failed.count >= maxLinkFailures {
+		return "", errors.New("too many failed link attempts; try again later")
+	}
+	if ok, _, _ := s.attempts.allow(account, now); !ok {
+		return "", errors.New("too many link attempts; try again in a minute")
+	}
+	if ok, _, _ := s.global.allow("link", now); !ok {
+		return "", errors.New("too many link attempts; try again in a minute")
+	}
+
+	code = strings.TrimSpace(code)
+	link, ok := s.pending[code]
+	if !ok || now.After(link.expires) {
+		delete(s.pending, code)
+		s.recordLinkFailureLocked(account, failed, now)
+		return "", errors.New("link code is invalid or expired")
+	}
+	if link.account == account {
+		return "", errors.New("confirm the code from your other account")
+	}
+
+	first, firstOK := s.lookupLocked(link.account)
+	second, secondOK := s.lookupLocked(account)
+	identity := first
+	switch {
+	case firstOK && secondOK && first != second:
+		s.recordLinkFailureLocked(account, failed, now)
+		delete(s.pending, code)
+		return "", fmt.Errorf("accounts already belong to different identities (%s, %s)", first, second)
+	case !firstOK && secondOK:
+		identity = second
+	case !firstOK && !secondOK:
+		suffix, err := randomHex(4)
+		if err != nil {
+			return "", err
+		}
+		identity = "person-" + suffix
+	}
+
+	delete(s.pending, code)
+	delete(s.failures, account)
+	s.linked[link.account] = identity
+	s.linked[account] = identity
+	if err := s.saveLocked(); err != nil {
+		return "", err
+	}
+	return identity, nil
+}
+
+// recordLinkFailureLocked counts a failed attempt against the account.
+func (s *IdentityStore) recordLinkFailureLocked(account string, failed linkFailures, now time.Time) {
+	if failed.count == 0 {
+		failed.since = now
+	}
+	failed.count++
+	s.failures[account] = failed
+}
+
+// Unlink removes a /link-created association for the account. Configured
+// people cannot be unlinked from chat.
+func (s *IdentityStore) Unlink(channel string, userID string) (bool, error) {
+	account := NormalizeAccount(channel, userID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.linked[account]; !ok {
+		return false, nil
+	}
+	delete(s.linked, account)
+	return true, s.saveLocked()
+}
+
+func (s *IdentityStore) lookupLocked(account string) (string, bool) {
+	if id, ok := s.static[account]; ok {
+		return id, true
+	}
+	id, ok := s.linked[account]
+	return id, ok
+}
+
+func (s *IdentityStore) load() error {
+	payload, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read identity links: %w", err)
+	}
+
+	var decoded identityFile
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return fmt.Errorf("parse identity links: %w", err)
+	}
+	for account, id := range decoded.Links {
+		s.linked[account] = id
+	}
+	return nil
+}
+
+func (s *IdentityStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	payload, err := json.MarshalIndent(identityFile{Links: s.linked}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create identity directory: %w", err)
+	}
+	tempFile, err := os.CreateTemp(dir, "identities-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp identity file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	if _, err := tempFile.Write(payload); err != nil {
+		_ = tempFile.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("write identity links: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("close identity links: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("replace identity links: %w", err)
+	}
+	return nil
+}
+
+func randomDigits(n int) (string, error) {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(byte('0' + digit.Int64()))
+	}
+	return b.String(), nil
+}
+
+func randomHex(bytes int) (string, error) {
+	buf := make([]byte, bytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func (a *Agent) handleLinkCommand(c caller, fields []string) (string, error) {
+	msg := c.msg
+	store, ok := a.identities.(*IdentityStore)
+	if !ok || store == nil {
+		return "Identity linking is not enabled.", nil
+	}
+
+	now := time.Now()
+	switch {
+	case strings.ToLower(fields[0]) == "/unlink":
+		removed, err := store.Unlink(msg.Channel, msg.UserID)
+		if err != nil {
+			a.recordCommand(c, "/unlink", nil, AuditStatusError, err)
+			return "", err
+		}
+		if !removed {
+			return "This account has no chat-created link.", nil
+		}
+		a.recordCommand(c, "/unlink", nil, AuditStatusOK, nil)
+		return "Account unlinked.", nil
+	case len(fields) == 1:
+		code, ttl, err := store.StartLink(msg.Channel, msg.UserID, now)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Send /link %s from your other account within %d minutes.", code, int(ttl.Minutes())), nil
+	default:
+		identity, err := store.CompleteLink(fields[1], msg.Channel, msg.UserID, now)
+		if err != nil {
+			a.logger.Warn("identity link failed", "channel", msg.Channel, "user_id", msg.UserID, "error", err)
+			a.recordCommand(c, "/link", nil, AuditStatusDenied, err)
+			return "Link failed: " + err.Error(), nil
+		}
+		a.logger.Info("linked identity", "identity", identity, "channel", msg.Channel, "user_id", msg.UserID)
+		a.recordCommand(c, "/link", map[string]any{"identity": identity}, AuditStatusOK, nil)
+		return fmt.Sprintf("Accounts linked as %s.", identity), nil
+	}
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompleteLink(t *testing.T) {
+	const ttl = 10 * time.Minute
+
+	// linkStep issues a code (start) or redeems one. code indexes the codes
+	// issued so far; -1 submits a code that was never issued.
+	type linkStep struct {
+		start   bool
+		account string
+		code    int
+		after   time.Duration
+		wantErr string
+	}
+	start := func(account string, after time.Duration) linkStep {
+		return linkStep{start: true, account: account, after: after}
+	}
+	redeem := func(account string, code int, after time.Duration, wantErr string) linkStep {
+		return linkStep{account: account, code: code, after: after, wantErr: wantErr}
+	}
+	wrongGuesses := func(account string, count int, after time.Duration, wantErr string) []linkStep {
+		steps := make([]linkStep, 0, count)
+		for i := 0; i < count; i++ {
+			steps = append(steps, redeem(account, -1, after+time.Duration(i)*time.Minute, wantErr))
+		}
+		return steps
+	}
+	repeat := func(step linkStep, count int) []linkStep {
+		steps := make([]linkStep, count)
+		for i := range steps {
+			steps[i] = step
+		}
+		return steps
+	}
+	concat := func(groups ...[]linkStep) []linkStep {
+		var steps []linkStep
+		for _, group := range groups {
+			steps = append(steps, group...)
+		}
+		return steps
+	}
+
+	tests := []struct {
+		name   string
+		people []Person
+		steps  []linkStep
+	}{
+		{
+			name: "links two accounts",
+			steps: []linkStep{
+				start("telegram:1", 0),
+				redeem("whatsapp:60123", 0, time.Minute, ""),
+			},
+		},
+		{
+			name: "caps failures per account and resets after the ttl",
+			steps: concat(
+				wrongGuesses("telegram:1", maxLinkFailures, 0, "invalid or expired"),
+				[]linkStep{
+					redeem("telegram:1", -1, 5*time.Minute, "too many failed link attempts"),
+					redeem("telegram:2", -1, 5*time.Minute, "invalid or expired"),
+					redeem("telegram:1", -1, ttl+time.Minute, "invalid or expired"),
+				},
+			),
+		},
+		{
+			name: "rate limits one account",
+			// Redeeming one's own code is refused without counting as a
+			// failure, so only the rate limiter stops the sixth attempt.
+			steps: concat(
+				[]linkStep{start("telegram:1", 0)},
+				repeat(redeem("telegram:1", 0, 0, "other account"), linkAttemptLimit.Burst),
+				[]linkStep{
+					redeem("telegram:1", 0, 0, "try again in a minute"),
+					redeem("whatsapp:60123", 0, 0, ""),
+					redeem("telegram:1", 0, time.Minute, "invalid or expired"),
+				},
+			),
+		},
+		{
+			name:  "rejects an expired code",
+			steps: []linkStep{start("telegram:1", 0), redeem("whatsapp:60123", 0, ttl+time.Second, "invalid or expired")},
+		},
+		{
+			name:  "rejects the issuing account",
+			steps: []linkStep{start("telegram:1", 0), redeem("telegram:1", 0, time.Minute, "other account")},
+		},
+		{
+			name: "rejects accounts of different identities",
+			people: []Person{
+				{ID: "alice", Accounts: []string{"telegram:1"}},
+				{ID: "bob", Accounts: []string{"whatsapp:60123"}},
+			},
+			steps: []linkStep{
+				start("telegram:1", 0),
+				redeem("whatsapp:60123", 0, time.Minute, "different identities"),
+				redeem("telegram:2", 0, 2*time.Minute, "invalid or expired"),
+			},
+		},
+		{
+			name: "start link revokes the earlier code",
+			steps: []linkStep{
+				start("telegram:1", 0),
+				start("telegram:1", time.Minute),
+				redeem("whatsapp:60123", 0, 2*time.Minute, "invalid or expired"),
+				redeem("whatsapp:60123", 1, 2*time.Minute, ""),
+			},
+		},
+	}
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewIdentityStore("", tt.people, ttl)
+			if err != nil {
+				t.Fatalf("NewIdentityStore: %v", err)
+			}
+
+			var codes []string
+			for i, step := range tt.steps {
+				channel, userID, _ := strings.Cut(step.account, ":")
+				now := base.Add(step.after)
+				if step.start {
+					code, _, err := store.StartLink(channel, userID, now)
+					if err != nil {
+						t.Fatalf("step %d: StartLink: %v", i, err)
+					}
+					codes = append(codes, code)
+					continue
+				}
+
+				code := "not-a-code"
+				if step.code >= 0 {
+					code = codes[step.code]
+				}
+				_, err := store.CompleteLink(code, channel, userID, now)
+				switch {
+				case step.wantErr == "" && err != nil:
+					t.Fatalf("step %d: CompleteLink: %v", i, err)
+				case step.wantErr != "" && (err == nil || !strings.Contains(err.Error(), step.wantErr)):
+					t.Fatalf("step %d: CompleteLink error = %v, want %q", i, err, step.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestCompleteLinkGlobalLimit(t *testing.T) {
+	store, err := NewIdentityStore("", nil, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewIdentityStore: %v", err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < linkGlobalAttemptLimit.Burst; i++ {
+		_, err := store.CompleteLink("not-a-code", "telegram", fmt.Sprint(i), now)
+		if err == nil || !strings.Contains(err.Error(), "invalid or expired") {
+			t.Fatalf("attempt %d: error = %v, want invalid code", i, err)
+		}
+	}
+
+	_, err = store.CompleteLink("not-a-code", "telegram", "fresh", now)
+	if err == nil || !strings.Contains(err.Error(), "try again in a minute") {
+		t.Fatalf("error past the global limit = %v, want rate limit", err)
+	}
+
+	_, err = store.CompleteLink("not-a-code", "telegram", "fresh", now.Add(time.Minute))
+	if err == nil || !strings.Contains(err.Error(), "invalid or expired") {
+		t.Fatalf("error after refill = %v, want invalid code", err)
+	}
+}
@@ -0,0 +1,189 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit allows PerMinute messages on average with bursts of up to Burst.
+// A zero PerMinute disables the limit.
+type RateLimit struct {
+	PerMinute float64
+	Burst     int
+}
+
+type tokenBucket struct {
+	tokens   float64
+	last     time.Time
+	notified bool
+}
+
+// rateLimiter keeps one token bucket per key.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   RateLimit
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+	if limit.Burst <= 0 {
+		limit.Burst = max(1, int(math.Ceil(limit.PerMinute/6)))
+	}
+	return &rateLimiter{
+		limit:   limit,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token for key. When none is left it returns the wait until
+// the next one and whether the caller was already told to slow down.
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration, bool) {
+	if l == nil {
+		return true, 0, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	perSecond := l.limit.PerMinute / 60
+	burst := float64(l.limit.Burst)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		// Prune before adding, so the new bucket is not mistaken for an
+		// idle one and dropped along with its first token.
+		if len(l.buckets) >= 1024 {
+			l.pruneLocked(now)
+		}
+		bucket = &tokenBucket{tokens: burst, last: now}
+		l.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens = math.Min(burst, bucket.tokens+elapsed*perSecond)
+		bucket.last = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.notified = false
+		return true, 0, false
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / perSecond * float64(time.Second))
+	notified := bucket.notified
+	bucket.notified = true
+	return false, wait, notified
+}
+
+// pruneLocked drops buckets that have refilled, since they behave exactly
+// like a new bucket.
+func (l *rateLimiter) pruneLocked(now time.Time) {
+	perSecond := l.limit.PerMinute / 60
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*perSecond >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// sessionQueue runs messages of one session one at a time, in arrival order.
+type sessionQueue struct {
+	mu    sync.Mutex
+	slots map[string]*sessionSlot
+}
+
+type sessionSlot struct {
+	turn    chan struct{}
+	waiters int
+}
+
+func newSessionQueue() *sessionQueue {
+	return &sessionQueue{slots: make(map[string]*sessionSlot)}
+}
+
+// acquire waits for the session's turn and returns the function that ends
+// it.
+func (q *sessionQueue) acquire(ctx context.Context, sessionKey string) (func(), error) {
+	q.mu.Lock()
+	slot, ok := q.slots[sessionKey]
+	if !ok {
+		slot = &sessionSlot{turn: make(chan struct{}, 1)}
+		q.slots[sessionKey] = slot
+	}
+	slot.waiters++
+	q.mu.Unlock()
+
+	leave := func() {
+		q.mu.Lock()
+		slot.waiters--
+		if slot.waiters == 0 {
+			delete(q.slots, sessionKey)
+		}
+		q.mu.Unlock()
+	}
+
+	select {
+	case slot.turn <- struct{}{}:
+		return func() {
+			<-slot.turn
+			leave()
+		}, nil
+	case <-ctx.Done():
+		leave()
+		return nil, ctx.Err()
+	}
+}
+
+// semaphore caps concurrent work. A nil semaphore never blocks.
+type semaphore chan struct{}
+
+func newSemaphore(size int) semaphore {
+	if size <= 0 {
+		return nil
+	}
+	return make(semaphore, size)
+}
+
+func (s semaphore) acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	select {
+	case s <- struct{}{}:
+		return func() { <-s }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// checkRateLimits applies the per-user and per-channel limits. reply is empty
+// when the sender was already told to slow down and should get no answer.
+// Buckets run on the local clock: gateway timestamps can be coarse or replayed
+// out of order after a reconnect.
+func (a *Agent) checkRateLimits(msg Message) (limited bool, reply string) {
+	settings := a.settings.Load()
+	user := a.usageUser(msg)
+	now := time.Now()
+	allowed, wait, notified := settings.userLimiter.allow(user, now)
+	scope := "you are"
+	if allowed {
+		if allowed, wait, notified = settings.channelLimiter.allow(msg.Channel, now); !allowed {
+			scope = msg.Channel + " is"
+		}
+	}
+	if allowed {
+		return false, ""
+	}
+
+	if !notified {
+		a.logger.Warn("message rate limited", "channel", msg.Channel, "user_id", msg.UserID, "scope", scope, "retry_after", wait)
+		return true, fmt.Sprintf("Slow down: %s sending messages too quickly. Try again in %d seconds.", scope, int(math.Ceil(wait.Seconds())))
+	}
+	return true, ""
+}
@@ -0,0 +1,218 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterRefillAndBurst(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{PerMinute: 60, Burst: 3})
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		after   time.Duration
+		allowed bool
+	}{
+		{0, true},
+		{0, true},
+		{0, true},
+		{0, false},
+		{500 * time.Millisecond, false},
+		{time.Second, true},
+		{time.Second, false},
+		// A long pause refills only up to the burst.
+		{time.Hour, true},
+		{time.Hour, true},
+		{time.Hour, true},
+		{time.Hour, false},
+	}
+	for i, step := range steps {
+		allowed, wait, _ := limiter.allow("alice", start.Add(step.after))
+		if allowed != step.allowed {
+			t.Fatalf("step %d: allowed = %v, want %v", i, allowed, step.allowed)
+		}
+		if !allowed && (wait <= 0 || wait > time.Second) {
+			t.Errorf("step %d: wait = %s, want (0, 1s]", i, wait)
+		}
+	}
+
+	if allowed, _, _ := limiter.allow("bob", start); !allowed {
+		t.Error("a second key shares the first key's bucket")
+	}
+	if allowed, _, _ := (*rateLimiter)(nil).allow("alice", start); !allowed {
+		t.Error("a nil limiter refused a message")
+	}
+	if newRateLimiter(RateLimit{}) != nil {
+		t.Error("a zero rate built a limiter")
+	}
+}
+
+func TestRateLimiterNotifiesOncePerStretch(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{PerMinute: 60, Burst: 1})
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	steps := []struct {
+		after    time.Duration
+		allowed  bool
+		notified bool
+	}{
+		{0, true, false},
+		{0, false, false},
+		{100 * time.Millisecond, false, true},
+		{200 * time.Millisecond, false, true},
+		{time.Second, true, false},
+		{time.Second, false, false},
+		{time.Second, false, true},
+	}
+	for i, step := range steps {
+		allowed, _, notified := limiter.allow("alice", start.Add(step.after))
+		if allowed != step.allowed || notified != step.notified {
+			t.Fatalf("step %d: allow = %v, notified %v; want %v, %v", i, allowed, notified, step.allowed, step.notified)
+		}
+	}
+}
+
+func TestRateLimiterPrunesRefilledBuckets(t *testing.T) {
+	limiter := newRateLimiter(RateLimit{PerMinute: 60, Burst: 1})
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 1024; i++ {
+		limiter.allow(fmt.Sprintf("user-%d", i), start)
+	}
+
+	// No bucket has refilled yet, so all of them survive the prune that
+	// the next new key triggers, and that key keeps its spent token.
+	limiter.allow("late", start)
+	if got := len(limiter.buckets); got != 1025 {
+		t.Fatalf("buckets after pruning drained ones = %d, want 1025", got)
+	}
+	if allowed, _, _ := limiter.allow("late", start); allowed {
+		t.Fatal("the key that triggered pruning lost its spent token")
+	}
+
+	// Once they have refilled, they behave like new buckets and go.
+	limiter.allow("later", start.Add(2*time.Second))
+	if got := len(limiter.buckets); got != 1 {
+		t.Fatalf("buckets after pruning refilled ones = %d, want 1", got)
+	}
+}
+
+func TestSessionQueueRunsInArrivalOrder(t *testing.T) {
+	queue := newSessionQueue()
+	ctx := context.Background()
+
+	release, err := queue.acquire(ctx, "chat")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	order := make(chan int, 3)
+	for i := 1; i <= 3; i++ {
+		go func() {
+			done, err := queue.acquire(ctx, "chat")
+			if err != nil {
+				t.Errorf("acquire %d: %v", i, err)
+				return
+			}
+			order <- i
+			done()
+		}()
+		waitForWaiters(t, queue, "chat", i+1)
+	}
+
+	release()
+	for want := 1; want <= 3; want++ {
+		if got := <-order; got != want {
+			t.Fatalf("turn %d went to waiter %d", want, got)
+		}
+	}
+	waitForWaiters(t, queue, "chat", 0)
+}
+
+func TestSessionQueueCancelledWaiterLeaves(t *testing.T) {
+	queue := newSessionQueue()
+
+	release, err := queue.acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := queue.acquire(ctx, "chat")
+		result <- err
+	}()
+	waitForWaiters(t, queue, "chat", 2)
+
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled acquire error = %v, want context.Canceled", err)
+	}
+	waitForWaiters(t, queue, "chat", 1)
+
+	release()
+	waitForWaiters(t, queue, "chat", 0)
+
+	// The session is usable again after its slot was removed.
+	release, err = queue.acquire(context.Background(), "chat")
+	if err != nil {
+		t.Fatalf("acquire after cleanup: %v", err)
+	}
+	release()
+}
+
+// waitForWaiters polls until the session has want waiters; zero means its
+// slot was removed. A short pause then lets a new waiter block on its turn.
+func waitForWaiters(t *testing.T, queue *sessionQueue, sessionKey string, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		queue.mu.Lock()
+		slot, ok := queue.slots[sessionKey]
+		got := 0
+		if ok {
+			got = slot.waiters
+		}
+		queue.mu.Unlock()
+		if got == want && (want > 0 || !ok) {
+			time.Sleep(10 * time.Millisecond)
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session %s has %d waiters, want %d", sessionKey, got, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSemaphore(t *testing.T) {
+	var unlimited semaphore
+	for i := 0; i < 3; i++ {
+		if _, err := unlimited.acquire(context.Background()); err != nil {
+			t.Fatalf("nil semaphore acquire: %v", err)
+		}
+	}
+
+	slots := newSemaphore(1)
+	release, err := slots.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := slots.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire on a full semaphore = %v, want deadline exceeded", err)
+	}
+
+	release()
+	release, err = slots.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release()
+}
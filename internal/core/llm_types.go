@@ -1,6 +1,9 @@
 package core
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type ToolDefinition struct {
 	Name        string
@@ -24,8 +27,70 @@ type LLMMessage struct {
 type LLMResponse struct {
 	Content   string
 	ToolCalls []ToolCall
+
+	// Model names the model that answered. The token counts stay zero when
+	// the provider does not report usage.
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
 }
 
 type ChatProvider interface {
 	Complete(ctx context.Context, messages []LLMMessage, tools []ToolDefinition) (LLMResponse, error)
 }
+
+// StreamingProvider is implemented by providers that can deliver text as it
+// is generated. onText receives the full assistant text of the current
+// attempt so far, so a retried request simply starts over.
+type StreamingProvider interface {
+	ChatProvider
+	CompleteStream(ctx context.Context, messages []LLMMessage, tools []ToolDefinition, onText PartialFunc) (LLMResponse, error)
+}
+
+// ProviderStats counts how often one configured provider was tried and how
+// often it produced the answer.
+type ProviderStats struct {
+	Name         string    `json:"name"`
+	Attempts     int       `json:"attempts"`
+	Answered     int       `json:"answered"`
+	Failures     int       `json:"failures"`
+	LastError    string    `json:"last_error,omitempty"`
+	LastAnswered time.Time `json:"last_answered,omitzero"`
+	Latency      Histogram `json:"-"`
+}
+
+// LatencyBuckets are the upper bounds, in seconds, of LLM request latency
+// histograms.
+var LatencyBuckets = []float64{0.25, 0.5, 1, 2, 5, 10, 20, 30, 60, 120}
+
+// Histogram counts observations per LatencyBuckets bound, non-cumulatively;
+// the extra last count holds observations above every bound.
+type Histogram struct {
+	Counts []uint64
+	Sum    float64
+	Count  uint64
+}
+
+func (h *Histogram) Observe(seconds float64) {
+	if h.Counts == nil {
+		h.Counts = make([]uint64, len(LatencyBuckets)+1)
+	}
+	i := 0
+	for i < len(LatencyBuckets) && seconds > LatencyBuckets[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Sum += seconds
+	h.Count++
+}
+
+// Clone returns a copy that does not share Counts.
+func (h Histogram) Clone() Histogram {
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// ProviderStatsSource is implemented by providers that wrap several others.
+type ProviderStatsSource interface {
+	ProviderStats() []ProviderStats
+}
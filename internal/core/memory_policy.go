@@ -0,0 +1,73 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MemorySharing decides which chats share one conversation session.
+type MemorySharing string
+
+const (
+	// MemoryIsolated keeps one session per channel chat.
+	MemoryIsolated MemorySharing = "isolated"
+	// MemoryPerUser keeps one session per user, shared by all of that user's
+	// chats and, for linked identities, across channels.
+	MemoryPerUser MemorySharing = "user"
+	// MemoryLinked isolates chats unless the sender has a linked identity.
+	MemoryLinked MemorySharing = "linked"
+	// MemoryGlobal puts every message from every chat into one session.
+	MemoryGlobal MemorySharing = "global"
+)
+
+func ParseMemorySharing(value string) (MemorySharing, error) {
+	switch MemorySharing(strings.ToLower(strings.TrimSpace(value))) {
+	case "", MemoryIsolated:
+		return MemoryIsolated, nil
+	case MemoryPerUser:
+		return MemoryPerUser, nil
+	case MemoryLinked:
+		return MemoryLinked, nil
+	case MemoryGlobal:
+		return MemoryGlobal, nil
+	default:
+		return "", fmt.Errorf("unsupported memory sharing policy %q", value)
+	}
+}
+
+// IdentityResolver maps a channel-specific user ID to a stable person ID
+// shared by all of that person's linked accounts.
+type IdentityResolver interface {
+	ResolveIdentity(channel string, userID string) (string, bool)
+}
+
+type memoryEntry struct {
+	sessionKey string
+	message    Message
+}
+
+func (a *Agent) sessionKeyFor(msg Message) string {
+	switch a.sharing {
+	case MemoryGlobal:
+		return "global"
+	case MemoryPerUser:
+		if identity, ok := a.resolveIdentity(msg); ok {
+			return "identity:" + identity
+		}
+		if userID := strings.TrimSpace(msg.UserID); userID != "" && strings.TrimSpace(msg.Channel) != "" {
+			return "user:" + msg.Channel + ":" + userID
+		}
+	case MemoryLinked:
+		if identity, ok := a.resolveIdentity(msg); ok {
+			return "identity:" + identity
+		}
+	}
+	return messageSessionKey(msg)
+}
+
+func (a *Agent) resolveIdentity(msg Message) (string, bool) {
+	if a.identities == nil || strings.TrimSpace(msg.UserID) == "" {
+		return "", false
+	}
+	return a.identities.ResolveIdentity(msg.Channel, msg.UserID)
+}
@@ -16,3 +16,13 @@ type Message struct {
 type Processor interface {
 	Process(ctx context.Context, msg Message) (string, error)
 }
+
+// PartialFunc receives the reply text produced so far while it streams.
+type PartialFunc func(text string)
+
+// StreamProcessor is implemented by processors that can report a reply while
+// it is still being generated. The returned string is the complete reply.
+type StreamProcessor interface {
+	Processor
+	ProcessStream(ctx context.Context, msg Message, onPartial PartialFunc) (string, error)
+}
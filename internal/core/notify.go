@@ -0,0 +1,151 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Notifier sends a message that is not a reply, such as an alert or a
+// reminder. chatID is a Telegram chat ID or a WhatsApp JID.
+type Notifier interface {
+	Send(ctx context.Context, chatID string, text string) error
+}
+
+// NotificationRouter delivers notifications through the gateway of a chat's
+// channel, or finds a user's accounts and tries their preferred channel
+// first.
+type NotificationRouter struct {
+	mu         sync.RWMutex
+	logger     *slog.Logger
+	identities *IdentityStore
+	preferred  map[string]string
+	order      []string
+	notifiers  map[string]Notifier
+}
+
+// NewNotificationRouter takes the per-user channel preferences, keyed by
+// identity or account, and the channel order used for everyone else.
+func NewNotificationRouter(identities *IdentityStore, preferred map[string]string, order []string, logger *slog.Logger) *NotificationRouter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	router := &NotificationRouter{
+		logger:     logger,
+		identities: identities,
+		preferred:  make(map[string]string, len(preferred)),
+		notifiers:  make(map[string]Notifier),
+	}
+	for user, channel := range preferred {
+		router.preferred[normalizeNotifyUser(user)] = strings.ToLower(strings.TrimSpace(channel))
+	}
+	for _, channel := range order {
+		if channel = strings.ToLower(strings.TrimSpace(channel)); channel != "" {
+			router.order = append(router.order, channel)
+		}
+	}
+	return router
+}
+
+// Register makes a gateway available for its channel.
+func (r *NotificationRouter) Register(channel string, notifier Notifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifiers[strings.ToLower(strings.TrimSpace(channel))] = notifier
+}
+
+// Send delivers text to one chat. It matches DeliverFunc.
+func (r *NotificationRouter) Send(ctx context.Context, channel string, chatID string, text string) error {
+	r.mu.RLock()
+	notifier, ok := r.notifiers[strings.ToLower(strings.TrimSpace(channel))]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("gateway %q is not enabled", channel)
+	}
+	if strings.TrimSpace(text) == "" {
+		return errors.New("notification text is empty")
+	}
+	return notifier.Send(ctx, chatID, text)
+}
+
+// NotifyUser sends text to the direct chat of user, given as an identity ID
+// or a "<channel>:<user id>" account. Accounts are tried in preference order
+// until one delivery succeeds; the channel used is returned.
+func (r *NotificationRouter) NotifyUser(ctx context.Context, user string, text string) (string, error) {
+	accounts := r.accounts(user)
+	if len(accounts) == 0 {
+		return "", fmt.Errorf("no chat account known for %q", user)
+	}
+
+	var lastErr error
+	for _, account := range accounts {
+		channel, chatID, _ := strings.Cut(account, ":")
+		err := r.Send(ctx, channel, chatID, text)
+		if err == nil {
+			return channel, nil
+		}
+		r.logger.Warn("notification delivery failed", "account", account, "error", err)
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// accounts lists the user's accounts, preferred channel first, then in the
+// configured channel order. An account named directly is always tried first.
+func (r *NotificationRouter) accounts(user string) []string {
+	user = normalizeNotifyUser(user)
+	if user == "" {
+		return nil
+	}
+
+	identity := user
+	named := 0
+	accounts := make([]string, 0, 4)
+	if channel, userID, ok := strings.Cut(user, ":"); ok {
+		accounts = append(accounts, user)
+		named = 1
+		identity, _ = r.identities.ResolveIdentity(channel, userID)
+	}
+	if identity != "" {
+		for _, account := range r.identities.Accounts(identity) {
+			if account != user {
+				accounts = append(accounts, account)
+			}
+		}
+	}
+
+	preferred := r.preferred[identity]
+	if named > 0 && r.preferred[user] != "" {
+		preferred = r.preferred[user]
+	}
+	rank := func(account string) int {
+		channel, _, _ := strings.Cut(account, ":")
+		if channel == preferred {
+			return -1
+		}
+		for i, ordered := range r.order {
+			if channel == ordered {
+				return i
+			}
+		}
+		return len(r.order)
+	}
+	others := accounts[named:]
+	sort.SliceStable(others, func(i, j int) bool {
+		return rank(others[i]) < rank(others[j])
+	})
+	return accounts
+}
+
+func normalizeNotifyUser(user string) string {
+	user = strings.TrimSpace(user)
+	if channel, userID, ok := strings.Cut(user, ":"); ok {
+		return NormalizeAccount(channel, userID)
+	}
+	return user
+}
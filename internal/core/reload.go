@@ -0,0 +1,37 @@
+package core
+
+import "strings"
+
+// ReloadOptions are the agent settings that can change while it runs.
+type ReloadOptions struct {
+	SystemPrompt     string
+	Access           *AccessPolicy
+	UserRateLimit    RateLimit
+	ChannelRateLimit RateLimit
+}
+
+// agentSettings is swapped as a whole by Reload, so a message is always
+// handled with one consistent set.
+type agentSettings struct {
+	systemPrompt   string
+	access         *AccessPolicy
+	userLimiter    *rateLimiter
+	channelLimiter *rateLimiter
+}
+
+// Reload replaces the system prompt, access policy and rate limits. Messages
+// already being answered finish with the old settings. The rate limiters
+// start over with full buckets.
+func (a *Agent) Reload(opts ReloadOptions) {
+	systemPrompt := opts.SystemPrompt
+	if strings.TrimSpace(systemPrompt) == "" {
+		systemPrompt = defaultSystemPrompt
+	}
+
+	a.settings.Store(&agentSettings{
+		systemPrompt:   systemPrompt,
+		access:         opts.Access,
+		userLimiter:    newRateLimiter(opts.UserRateLimit),
+		channelLimiter: newRateLimiter(opts.ChannelRateLimit),
+	})
+}
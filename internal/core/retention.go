@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RunRetention prunes stored sessions and in-memory history on every tick
+// until ctx is cancelled.
+func (a *Agent) RunRetention(ctx context.Context, policy RetentionPolicy, interval time.Duration) {
+	if !policy.Enabled() {
+		return
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		a.prune(policy, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Agent) prune(policy RetentionPolicy, now time.Time) {
+	if policy.MaxAge > 0 {
+		cutoff := now.Add(-policy.MaxAge)
+		a.mu.Lock()
+		drop := 0
+		for drop < len(a.memory) && a.memory[drop].message.Timestamp.Before(cutoff) {
+			drop++
+		}
+		a.memory = append([]memoryEntry(nil), a.memory[drop:]...)
+		a.mu.Unlock()
+	}
+
+	result, err := a.sessions.Prune(policy, now)
+	if result.RemovedMessages > 0 {
+		a.cancelCompaction()
+	}
+	if err != nil {
+		a.logger.Warn("session pruning failed", "error", err)
+	}
+	if result.RemovedMessages > 0 || result.RemovedSessions > 0 {
+		a.logger.Info("pruned session history", "messages", result.RemovedMessages, "sessions", result.RemovedSessions)
+	}
+}
+
+// resetSession clears the caller's session and any copies of its messages
+// that older versions mirrored into the global session. With global memory
+// sharing every user talks in one session, so only an admin may reset it.
+func (a *Agent) resetSession(c caller) (string, error) {
+	sessionKey := c.sessionKey
+	if sessionKey == "global" && c.role < RoleAdmin {
+		a.recordCommand(c, "/reset", nil, AuditStatusDenied, errors.New("the global session can only be reset by an admin"))
+		return "This conversation is shared by everyone, so only an admin can reset it. Use /forget to remove your own messages.", nil
+	}
+	if a.sessions == nil {
+		a.forgetSessionMemory(sessionKey)
+		a.recordCommand(c, "/reset", nil, AuditStatusOK, nil)
+		return "Conversation reset.", nil
+	}
+
+	removed, err := a.deleteSession(sessionKey)
+	a.recordCommand(c, "/reset", nil, AuditStatusOK, err)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Conversation reset. Removed %d stored messages.", removed), nil
+}
+
+// deleteSession clears a session from memory and storage and returns how
+// many stored messages it held.
+func (a *Agent) deleteSession(sessionKey string) (int, error) {
+	history := a.sessions.History(sessionKey)
+	a.forgetSessionMemory(sessionKey)
+	a.cancelCompaction(sessionKey, "global")
+
+	if err := a.sessions.Delete(sessionKey); err != nil {
+		return 0, err
+	}
+	if sessionKey != "global" && len(history) > 0 {
+		if _, err := a.sessions.RemoveMessages("global", func(item Message) bool {
+			for _, stored := range history {
+				if sameMessage(item, stored) {
+					return true
+				}
+			}
+			return false
+		}); err != nil {
+			return 0, err
+		}
+	}
+	return len(history), nil
+}
+
+func (a *Agent) forgetSessionMemory(sessionKey string) {
+	a.mu.Lock()
+	kept := a.memory[:0]
+	for _, entry := range a.memory {
+		if entry.sessionKey != sessionKey {
+			kept = append(kept, entry)
+		}
+	}
+	a.memory = kept
+	a.mu.Unlock()
+}
+
+// forgetUser removes every stored message sent by or answered to the caller,
+// across all sessions.
+func (a *Agent) forgetUser(c caller) (string, error) {
+	msg := c.msg
+	if msg.UserID == "" {
+		return "Cannot identify your messages.", nil
+	}
+
+	// User IDs are only unique within a channel. Replies are stored under
+	// the "assistant" channel, so they are matched by the chats the caller
+	// wrote in.
+	account := NormalizeAccount(msg.Channel, msg.UserID)
+	sentByCaller := func(item Message) bool {
+		return item.Channel != "assistant" && NormalizeAccount(item.Channel, item.UserID) == account
+	}
+	chats := map[string]bool{msg.ChatID: true}
+	a.mu.Lock()
+	for _, entry := range a.memory {
+		if sentByCaller(entry.message) {
+			chats[entry.message.ChatID] = true
+		}
+	}
+	a.mu.Unlock()
+	for _, key := range a.sessions.SessionKeys() {
+		for _, item := range a.sessions.History(key) {
+			if sentByCaller(item) {
+				chats[item.ChatID] = true
+			}
+		}
+	}
+
+	byCaller := func(item Message) bool {
+		if item.Channel == "assistant" {
+			return item.UserID == msg.UserID && chats[item.ChatID]
+		}
+		return sentByCaller(item)
+	}
+	a.forgetMemory(byCaller)
+
+	removed := 0
+	for _, key := range a.sessions.SessionKeys() {
+		count, err := a.sessions.RemoveMessages(key, byCaller)
+		if count > 0 {
+			a.cancelCompaction(key)
+		}
+		if err != nil {
+			a.recordCommand(c, "/forget", nil, AuditStatusError, err)
+			return "", err
+		}
+		removed += count
+	}
+	a.recordCommand(c, "/forget", nil, AuditStatusOK, nil)
+	return fmt.Sprintf("Forgot %d stored messages.", removed), nil
+}
+
+func (a *Agent) forgetMemory(match func(Message) bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	kept := a.memory[:0]
+	for _, entry := range a.memory {
+		if !match(entry.message) {
+			kept = append(kept, entry)
+		}
+	}
+	a.memory = kept
+}
@@ -0,0 +1,708 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ScheduleActionPrompt = "prompt"
+	ScheduleActionRemind = "remind"
+	ScheduleActionTool   = "tool"
+
+	// scheduleGrace is how late a recurring job may still run, for example
+	// after a restart. Older runs are skipped rather than replayed.
+	scheduleGrace = 10 * time.Minute
+
+	scheduleRunTimeout = 5 * time.Minute
+	scheduleUsage      = "Usage: /schedule <when> <prompt|remind|tool> <text or tool name> [key=value ...]\n" +
+		"when: in 2h | at 18:30 | at 2026-01-31 08:00 | daily 08:00 | every 30m | @daily | cron 0 8 * * 1-5"
+)
+
+// DeliverFunc sends text to a chat on the named channel.
+type DeliverFunc func(ctx context.Context, channel string, chatID string, text string) error
+
+// ScheduledJob runs once at At or repeatedly on Cron, and sends its result to
+// the chat it was created from.
+type ScheduledJob struct {
+	ID        string         `json:"id"`
+	Owner     string         `json:"owner"`
+	Channel   string         `json:"channel"`
+	UserID    string         `json:"user_id"`
+	ChatID    string         `json:"chat_id"`
+	Cron      string         `json:"cron,omitempty"`
+	At        time.Time      `json:"at,omitzero"`
+	Action    string         `json:"action"`
+	Text      string         `json:"text,omitempty"`
+	Tool      string         `json:"tool,omitempty"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Next      time.Time      `json:"next"`
+	Created   time.Time      `json:"created"`
+	LastRun   time.Time      `json:"last_run,omitzero"`
+	LastError string         `json:"last_error,omitempty"`
+}
+
+// Describe summarises the job on one line for chat listings.
+func (j ScheduledJob) Describe() string {
+	when := "once"
+	if j.Cron != "" {
+		when = "cron " + j.Cron
+	}
+	what := j.Action + ": " + j.Text
+	if j.Action == ScheduleActionTool {
+		what = "tool: " + j.Tool
+		if len(j.Arguments) > 0 {
+			args, _ := json.Marshal(j.Arguments)
+			what += " " + string(args)
+		}
+	}
+	return fmt.Sprintf("%s next %s (%s) %s", j.ID, j.Next.Local().Format("2006-01-02 15:04"), when, truncate(what, 120))
+}
+
+type scheduleFile struct {
+	Jobs []ScheduledJob `json:"jobs"`
+}
+
+// ScheduleStore keeps scheduled jobs in a JSON file so they survive
+// restarts.
+type ScheduleStore struct {
+	mu          sync.Mutex
+	path        string
+	maxPerOwner int
+	jobs        map[string]*ScheduledJob
+	wake        chan struct{}
+}
+
+func NewScheduleStore(path string, maxPerOwner int) (*ScheduleStore, error) {
+	store := &ScheduleStore{
+		path:        strings.TrimSpace(path),
+		maxPerOwner: maxPerOwner,
+		jobs:        make(map[string]*ScheduledJob),
+		wake:        make(chan struct{}, 1),
+	}
+	if store.path != "" {
+		if err := store.load(); err != nil {
+			return nil, err
+		}
+	}
+	return store, nil
+}
+
+// Add validates job, assigns its ID and first run time, and saves it.
+func (s *ScheduleStore) Add(job ScheduledJob, now time.Time) (ScheduledJob, error) {
+	switch job.Action {
+	case ScheduleActionPrompt, ScheduleActionRemind:
+		if strings.TrimSpace(job.Text) == "" {
+			return ScheduledJob{}, errors.New("text is required")
+		}
+	case ScheduleActionTool:
+		if strings.TrimSpace(job.Tool) == "" {
+			return ScheduledJob{}, errors.New("tool name is required")
+		}
+	default:
+		return ScheduledJob{}, fmt.Errorf("unknown action %q; use prompt, remind or tool", job.Action)
+	}
+
+	if job.Cron != "" {
+		schedule, err := ParseCron(job.Cron)
+		if err != nil {
+			return ScheduledJob{}, err
+		}
+		job.Cron = schedule.String()
+		job.At = time.Time{}
+		job.Next = schedule.Next(now)
+		if job.Next.IsZero() {
+			return ScheduledJob{}, fmt.Errorf("cron %q never fires", job.Cron)
+		}
+	} else {
+		if !job.At.After(now) {
+			return ScheduledJob{}, errors.New("time must be in the future")
+		}
+		job.Next = job.At
+	}
+	job.Created = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxPerOwner > 0 {
+		owned := 0
+		for _, existing := range s.jobs {
+			if existing.Owner == job.Owner {
+				owned++
+			}
+		}
+		if owned >= s.maxPerOwner {
+			return ScheduledJob{}, fmt.Errorf("limit of %d scheduled jobs reached; remove one with /unschedule", s.maxPerOwner)
+		}
+	}
+
+	for {
+		id, err := randomHex(3)
+		if err != nil {
+			return ScheduledJob{}, err
+		}
+		if _, exists := s.jobs[id]; !exists {
+			job.ID = id
+			break
+		}
+	}
+	s.jobs[job.ID] = &job
+	if err := s.saveLocked(); err != nil {
+		delete(s.jobs, job.ID)
+		return ScheduledJob{}, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (s *ScheduleStore) Get(id string) (ScheduledJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[strings.ToLower(strings.TrimSpace(id))]
+	if !ok {
+		return ScheduledJob{}, false
+	}
+	return *job, true
+}
+
+func (s *ScheduleStore) Remove(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id = strings.ToLower(strings.TrimSpace(id))
+	if _, ok := s.jobs[id]; !ok {
+		return false, nil
+	}
+	delete(s.jobs, id)
+	return true, s.saveLocked()
+}
+
+// List returns the jobs of owner, or every job when owner is empty, soonest
+// first.
+func (s *ScheduleStore) List(owner string) []ScheduledJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		if owner == "" || job.Owner == owner {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Next.Before(jobs[j].Next)
+	})
+	return jobs
+}
+
+// due claims the jobs whose time has come. Recurring jobs move on to their
+// next time and one-shot jobs are removed, so a job never runs twice even if
+// it is still running at the next tick. Recurring runs missed by more than
+// scheduleGrace are returned as skipped.
+func (s *ScheduleStore) due(now time.Time) (run []ScheduledJob, skipped []ScheduledJob, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, job := range s.jobs {
+		if job.Next.After(now) {
+			continue
+		}
+		if job.Cron == "" {
+			run = append(run, *job)
+			delete(s.jobs, id)
+			continue
+		}
+
+		if now.Sub(job.Next) > scheduleGrace {
+			skipped = append(skipped, *job)
+		} else {
+			run = append(run, *job)
+		}
+		schedule, parseErr := ParseCron(job.Cron)
+		if parseErr != nil {
+			delete(s.jobs, id)
+			continue
+		}
+		job.Next = schedule.Next(now)
+	}
+	if len(run) == 0 && len(skipped) == 0 {
+		return nil, nil, nil
+	}
+	return run, skipped, s.saveLocked()
+}
+
+// finished records the outcome of a recurring job's run.
+func (s *ScheduleStore) finished(id string, at time.Time, runErr error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+	job.LastRun = at
+	job.LastError = ""
+	if runErr != nil {
+		job.LastError = runErr.Error()
+	}
+	return s.saveLocked()
+}
+
+func (s *ScheduleStore) nextRun() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, job := range s.jobs {
+		if next.IsZero() || job.Next.Before(next) {
+			next = job.Next
+		}
+	}
+	return next
+}
+
+func (s *ScheduleStore) load() error {
+	payload, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read schedules: %w", err)
+	}
+
+	var decoded scheduleFile
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return fmt.Errorf("parse schedules: %w", err)
+	}
+	for _, job := range decoded.Jobs {
+		job := job
+		s.jobs[job.ID] = &job
+	}
+	return nil
+}
+
+func (s *ScheduleStore) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+
+	jobs := make([]ScheduledJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	payload, err := json.MarshalIndent(scheduleFile{Jobs: jobs}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create schedule directory: %w", err)
+	}
+	tempFile, err := os.CreateTemp(dir, "schedules-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp schedule file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	if _, err := tempFile.Write(payload); err != nil {
+		_ = tempFile.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("write schedules: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("close schedules: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("replace schedules: %w", err)
+	}
+	return nil
+}
+
+// ParseScheduleTime reads a schedule from the start of fields and returns
+// either a cron expression or a one-shot time, plus how many fields it used.
+func ParseScheduleTime(fields []string, now time.Time) (cron string, at time.Time, used int, err error) {
+	if len(fields) == 0 {
+		return "", time.Time{}, 0, errors.New("missing time")
+	}
+
+	switch keyword := strings.ToLower(fields[0]); {
+	case keyword == "in":
+		if len(fields) < 2 {
+			return "", time.Time{}, 0, errors.New("`in` needs a duration such as 2h or 90m")
+		}
+		delay, err := parseScheduleDuration(fields[1])
+		if err != nil {
+			return "", time.Time{}, 0, err
+		}
+		return "", now.Add(delay), 2, nil
+	case keyword == "at":
+		if len(fields) < 2 {
+			return "", time.Time{}, 0, errors.New("`at` needs HH:MM or YYYY-MM-DD HH:MM")
+		}
+		if len(fields) >= 3 {
+			if at, err := time.ParseInLocation("2006-01-02 15:04", fields[1]+" "+fields[2], time.Local); err == nil {
+				return "", at, 3, nil
+			}
+		}
+		clock, err := time.ParseInLocation("15:04", fields[1], time.Local)
+		if err != nil {
+			return "", time.Time{}, 0, fmt.Errorf("invalid time %q; use HH:MM or YYYY-MM-DD HH:MM", fields[1])
+		}
+		local := now.Local()
+		at := time.Date(local.Year(), local.Month(), local.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return "", at, 2, nil
+	case keyword == "daily":
+		if len(fields) < 2 {
+			return "", time.Time{}, 0, errors.New("`daily` needs HH:MM")
+		}
+		clock, err := time.Parse("15:04", fields[1])
+		if err != nil {
+			return "", time.Time{}, 0, fmt.Errorf("invalid time %q; use HH:MM", fields[1])
+		}
+		return fmt.Sprintf("%d %d * * *", clock.Minute(), clock.Hour()), time.Time{}, 2, nil
+	case keyword == "every":
+		if len(fields) < 2 {
+			return "", time.Time{}, 0, errors.New("`every` needs a duration such as 30m")
+		}
+		every, err := parseScheduleDuration(fields[1])
+		if err != nil {
+			return "", time.Time{}, 0, err
+		}
+		return "@every " + every.String(), time.Time{}, 2, nil
+	case keyword == "cron":
+		if len(fields) < 6 {
+			return "", time.Time{}, 0, errors.New("`cron` needs five fields: minute hour day month weekday")
+		}
+		expr := strings.Join(fields[1:6], " ")
+		if _, err := ParseCron(expr); err != nil {
+			return "", time.Time{}, 0, err
+		}
+		return expr, time.Time{}, 6, nil
+	case strings.HasPrefix(keyword, "@"):
+		if _, err := ParseCron(keyword); err != nil {
+			return "", time.Time{}, 0, err
+		}
+		return keyword, time.Time{}, 1, nil
+	default:
+		return "", time.Time{}, 0, fmt.Errorf("unknown time %q", fields[0])
+	}
+}
+
+// parseScheduleDuration accepts Go durations plus a "d" suffix for days.
+func parseScheduleDuration(raw string) (time.Duration, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	delay, err := time.ParseDuration(raw)
+	if err != nil || delay < time.Minute {
+		return 0, fmt.Errorf("invalid duration %q; use at least one minute, e.g. 30m, 2h or 1d", raw)
+	}
+	return delay, nil
+}
+
+// RunSchedules runs due jobs and delivers their output until ctx is
+// cancelled.
+func (a *Agent) RunSchedules(ctx context.Context, deliver DeliverFunc) {
+	if a.schedules == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		now := time.Now()
+		run, skipped, err := a.schedules.due(now)
+		if err != nil {
+			a.logger.Warn("failed to save schedules", "error", err)
+		}
+		for _, job := range skipped {
+			a.logger.Warn("scheduled job missed; skipping to next run", "id", job.ID, "due", job.Next)
+		}
+		for _, job := range run {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.runScheduledJob(ctx, job, deliver)
+			}()
+		}
+
+		wait := time.Minute
+		if next := a.schedules.nextRun(); !next.IsZero() {
+			wait = min(wait, max(time.Until(next), time.Second))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-a.schedules.wake:
+			timer.Stop()
+		}
+	}
+}
+
+func (a *Agent) runScheduledJob(ctx context.Context, job ScheduledJob, deliver DeliverFunc) {
+	ctx, cancel := context.WithTimeout(ctx, scheduleRunTimeout)
+	defer cancel()
+
+	started := time.Now()
+	msg := Message{
+		Channel:   job.Channel,
+		UserID:    job.UserID,
+		ChatID:    job.ChatID,
+		Text:      job.Text,
+		Timestamp: started,
+	}
+
+	reply, err := a.scheduledReply(ctx, job, msg)
+	if err != nil {
+		reply = fmt.Sprintf("Scheduled job %s failed: %v", job.ID, err)
+	}
+	if strings.TrimSpace(reply) != "" && deliver != nil {
+		if deliverErr := deliver(ctx, job.Channel, job.ChatID, reply); deliverErr != nil {
+			a.logger.Error("failed to deliver scheduled job", "id", job.ID, "channel", job.Channel, "chat_id", job.ChatID, "error", deliverErr)
+			if err == nil {
+				err = deliverErr
+			}
+		}
+	}
+	if err != nil {
+		a.logger.Warn("scheduled job failed", "id", job.ID, "action", job.Action, "error", err)
+	} else {
+		a.logger.Info("scheduled job ran", "id", job.ID, "action", job.Action, "duration", time.Since(started))
+	}
+	if saveErr := a.schedules.finished(job.ID, started, err); saveErr != nil {
+		a.logger.Warn("failed to save schedules", "error", saveErr)
+	}
+}
+
+// scheduledReply runs the job with the owner's current role, so revoking
+// access also stops their jobs.
+func (a *Agent) scheduledReply(ctx context.Context, job ScheduledJob, msg Message) (string, error) {
+	role := a.roleFor(msg)
+	if role == RoleNone {
+		return "", errors.New("the job owner is no longer permitted")
+	}
+
+	switch job.Action {
+	case ScheduleActionRemind:
+		return "Reminder: " + job.Text, nil
+	case ScheduleActionPrompt:
+		return a.handle(ctx, msg, role, nil)
+	case ScheduleActionTool:
+		c := caller{msg: msg, sessionKey: a.sessionKeyFor(msg), role: role, origin: AuditOriginSchedule}
+		output, err := a.runTool(ctx, c, ToolCall{ID: "schedule_" + job.ID, Name: job.Tool, Arguments: job.Arguments})
+		if err != nil {
+			return "", err
+		}
+		return truncate(fmt.Sprintf("Scheduled job %s (%s):\n%s", job.ID, job.Tool, output), 4000), nil
+	default:
+		return "", fmt.Errorf("unknown action %q", job.Action)
+	}
+}
+
+// createSchedule stores a job for the caller after checking that the caller
+// may run the tool it names.
+func (a *Agent) createSchedule(c caller, job ScheduledJob) (ScheduledJob, error) {
+	if a.schedules == nil {
+		return ScheduledJob{}, errors.New("scheduling is not enabled")
+	}
+	if job.Action == ScheduleActionTool {
+		tool, ok := a.tools.Lookup(job.Tool)
+		if !ok {
+			return ScheduledJob{}, fmt.Errorf("unknown tool `%s`", job.Tool)
+		}
+		if required := a.toolRole(tool); !a.permits(c, required) {
+			return ScheduledJob{}, fmt.Errorf("%s requires the %s role", job.Tool, required)
+		}
+	}
+
+	job.Owner = a.usageUser(c.msg)
+	job.Channel = c.msg.Channel
+	job.UserID = c.msg.UserID
+	job.ChatID = c.msg.ChatID
+	created, err := a.schedules.Add(job, time.Now())
+	if err != nil {
+		return ScheduledJob{}, err
+	}
+	a.logger.Info("scheduled job created", "id", created.ID, "owner", created.Owner, "action", created.Action, "next", created.Next)
+	return created, nil
+}
+
+func (a *Agent) handleScheduleCommand(c caller, fields []string) (string, error) {
+	if a.schedules == nil {
+		return "Scheduling is not enabled.", nil
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "/schedules":
+		owner := a.usageUser(c.msg)
+		if len(fields) > 1 && strings.EqualFold(fields[1], "all") && c.role >= RoleAdmin {
+			owner = ""
+		}
+		jobs := a.schedules.List(owner)
+		if len(jobs) == 0 {
+			return "No scheduled jobs.", nil
+		}
+		lines := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			lines = append(lines, job.Describe())
+		}
+		return strings.Join(lines, "\n"), nil
+	case "/unschedule":
+		if len(fields) < 2 {
+			return "Usage: /unschedule <id>", nil
+		}
+		job, ok := a.schedules.Get(fields[1])
+		if !ok || (job.Owner != a.usageUser(c.msg) && c.role < RoleAdmin) {
+			return "No such scheduled job.", nil
+		}
+		_, err := a.schedules.Remove(job.ID)
+		a.recordCommand(c, "/unschedule", map[string]any{"id": job.ID, "owner": job.Owner}, AuditStatusOK, err)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Removed scheduled job %s.", job.ID), nil
+	}
+
+	cron, at, used, err := ParseScheduleTime(fields[1:], time.Now())
+	if err != nil {
+		return err.Error() + "\n" + scheduleUsage, nil
+	}
+	rest := fields[1+used:]
+	if len(rest) < 2 {
+		return scheduleUsage, nil
+	}
+
+	job := ScheduledJob{Cron: cron, At: at, Action: strings.ToLower(rest[0])}
+	if job.Action == ScheduleActionTool {
+		job.Tool = rest[1]
+		job.Arguments = make(map[string]any)
+		for _, pair := range rest[2:] {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				return fmt.Sprintf("Tool arguments must be key=value, got %q.", pair), nil
+			}
+			job.Arguments[key] = value
+		}
+	} else {
+		job.Text = strings.Join(rest[1:], " ")
+	}
+
+	created, err := a.createSchedule(c, job)
+	if err != nil {
+		return "Schedule failed: " + err.Error(), nil
+	}
+	a.recordCommand(c, "/schedule", map[string]any{"id": created.ID, "action": created.Action, "tool": created.Tool}, AuditStatusOK, nil)
+	return fmt.Sprintf("Scheduled %s. Next run %s. Remove it with /unschedule %s.", created.ID, created.Next.Local().Format("2006-01-02 15:04"), created.ID), nil
+}
+
+// scheduleTool lets the LLM create jobs for the person it is talking to.
+type scheduleTool struct {
+	agent *Agent
+}
+
+func (t *scheduleTool) Name() string { return "schedule_task" }
+
+func (t *scheduleTool) Available() bool { return t.agent.schedules != nil }
+
+func (t *scheduleTool) RequiredRole() Role { return RoleViewer }
+
+func (t *scheduleTool) Definition() ToolDefinition {
+	return ToolDefinition{
+		Name: t.Name(),
+		Description: "Schedule a reminder, a prompt or a tool call for the current chat. " +
+			"The result is sent to this chat when the job runs. Use remind for plain reminders.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"when": map[string]any{
+					"type":        "string",
+					"description": "One of: \"in 2h\", \"at 18:30\", \"at 2026-01-31 08:00\", \"daily 08:00\", \"every 30m\", \"@daily\", \"cron 0 8 * * 1-5\". Times are server local time.",
+				},
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{ScheduleActionRemind, ScheduleActionPrompt, ScheduleActionTool},
+					"description": "remind sends text as is, prompt asks the assistant, tool runs a tool",
+				},
+				"text": map[string]any{
+					"type":        "string",
+					"description": "Reminder text or prompt, for remind and prompt",
+				},
+				"tool": map[string]any{
+					"type":        "string",
+					"description": "Tool name, for the tool action",
+				},
+				"arguments": map[string]any{
+					"type":        "object",
+					"description": "Tool arguments, for the tool action",
+				},
+			},
+			"required": []string{"when", "action"},
+		},
+	}
+}
+
+func (t *scheduleTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	c, ok := callerFrom(ctx)
+	if !ok {
+		return "", errors.New("schedule_task needs a chat to report to")
+	}
+
+	fields := strings.Fields(StringArgument(args, "when"))
+	cron, at, used, err := ParseScheduleTime(fields, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if used != len(fields) {
+		return "", fmt.Errorf("unexpected text after time: %q", strings.Join(fields[used:], " "))
+	}
+
+	job := ScheduledJob{
+		Cron:   cron,
+		At:     at,
+		Action: strings.ToLower(StringArgument(args, "action")),
+		Text:   StringArgument(args, "text"),
+		Tool:   StringArgument(args, "tool"),
+	}
+	if arguments, ok := args["arguments"].(map[string]any); ok {
+		job.Arguments = arguments
+	}
+
+	created, err := t.agent.createSchedule(c, job)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Scheduled job %s, next run %s.", created.ID, created.Next.Local().Format("2006-01-02 15:04")), nil
+}
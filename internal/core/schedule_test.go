@@ -0,0 +1,66 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	utc := useLocation(t, "UTC")
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, utc)
+
+	tests := []struct {
+		name     string
+		input    string
+		wantCron string
+		wantAt   time.Time
+		wantUsed int
+	}{
+		{"at later today", "at 11:30 water plants", "", time.Date(2026, 3, 2, 11, 30, 0, 0, utc), 2},
+		{"at rolls to tomorrow", "at 09:00 standup", "", time.Date(2026, 3, 3, 9, 0, 0, 0, utc), 2},
+		{"at the current minute rolls to tomorrow", "at 10:00", "", time.Date(2026, 3, 3, 10, 0, 0, 0, utc), 2},
+		{"at a date", "at 2026-03-10 08:15 dentist", "", time.Date(2026, 3, 10, 8, 15, 0, 0, utc), 3},
+		{"in minutes", "in 90m", "", now.Add(90 * time.Minute), 2},
+		{"in days", "in 1d", "", now.Add(24 * time.Hour), 2},
+		{"daily", "daily 07:30 backup", "30 7 * * *", time.Time{}, 2},
+		{"every minutes", "every 30m", "@every 30m0s", time.Time{}, 2},
+		{"every day", "every 1d", "@every 24h0m0s", time.Time{}, 2},
+		{"cron", "cron 0 8 * * mon report", "0 8 * * mon", time.Time{}, 6},
+		{"shortcut", "@daily digest", "@daily", time.Time{}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, at, used, err := ParseScheduleTime(strings.Fields(tt.input), now)
+			if err != nil {
+				t.Fatalf("ParseScheduleTime(%q): %v", tt.input, err)
+			}
+			if cron != tt.wantCron || !at.Equal(tt.wantAt) || used != tt.wantUsed {
+				t.Errorf("ParseScheduleTime(%q) = %q, %s, %d; want %q, %s, %d",
+					tt.input, cron, at, used, tt.wantCron, tt.wantAt, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestParseScheduleTimeErrors(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for _, input := range []string{
+		"",
+		"tomorrow",
+		"at",
+		"at 25:00",
+		"in 30s",
+		"in 0d",
+		"every soon",
+		"daily 7pm",
+		"cron 0 8 * *",
+		"cron 0 8 * * funday",
+		"@sometimes",
+	} {
+		if _, _, _, err := ParseScheduleTime(strings.Fields(input), now); err == nil {
+			t.Errorf("ParseScheduleTime(%q) succeeded, want an error", input)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// JSONSessionBackend stores one indented JSON file per session and rewrites
+// the whole file on every change.
+type JSONSessionBackend struct {
+	dir string
+}
+
+func NewJSONSessionBackend(dir string) (*JSONSessionBackend, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil, errors.New("session directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &JSONSessionBackend{dir: dir}, nil
+}
+
+func (b *JSONSessionBackend) LoadSessions() ([]Session, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(b.dir, entry.Name())
+		payload, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var session Session
+		if err := json.Unmarshal(payload, &session); err != nil {
+			continue
+		}
+		if strings.TrimSpace(session.Key) == "" {
+			continue
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+func (b *JSONSessionBackend) SaveSession(session Session) error {
+	targetPath, err := b.sessionPath(session.Key)
+	if err != nil {
+		return err
+	}
+	if session.Messages == nil {
+		session.Messages = make([]Message, 0)
+	}
+
+	payload, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(b.dir, "session-*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := tempFile.Name()
+	cleanup := true
+	defer func() {
+		if cleanup {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := tempFile.Write(payload); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Chmod(0o644); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		_ = tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, targetPath); err != nil {
+		return err
+	}
+
+	cleanup = false
+	return nil
+}
+
+func (b *JSONSessionBackend) DeleteSession(key string) error {
+	targetPath, err := b.sessionPath(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(targetPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (b *JSONSessionBackend) Close() error {
+	return nil
+}
+
+// Path returns the file that holds the given session.
+func (b *JSONSessionBackend) Path(key string) (string, error) {
+	return b.sessionPath(key)
+}
+
+func (b *JSONSessionBackend) sessionPath(key string) (string, error) {
+	filename := sanitizeSessionFilename(key)
+	if filename == "" || filename == "." || !filepath.IsLocal(filename) || strings.ContainsAny(filename, `/\`) {
+		return "", os.ErrInvalid
+	}
+	return filepath.Join(b.dir, filename+".json"), nil
+}
+
+func sanitizeSessionFilename(key string) string {
+	return strings.ReplaceAll(strings.TrimSpace(key), ":", "_")
+}
@@ -0,0 +1,261 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy bounds stored history. Zero values disable a limit.
+type RetentionPolicy struct {
+	MaxAge                time.Duration
+	MaxMessagesPerSession int
+	MaxTotalBytes         int64
+}
+
+type PruneResult struct {
+	RemovedMessages int `json:"removed_messages"`
+	RemovedSessions int `json:"removed_sessions"`
+}
+
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxMessagesPerSession > 0 || p.MaxTotalBytes > 0
+}
+
+// Delete drops a session and its stored messages.
+func (s *SessionStore) Delete(sessionKey string) error {
+	if s == nil {
+		return nil
+	}
+
+	key := strings.TrimSpace(sessionKey)
+	defer s.lockKey(key)()
+	return s.deleteSession(key)
+}
+
+// deleteSession is Delete for callers that hold the session's write lock.
+func (s *SessionStore) deleteSession(key string) error {
+	s.mu.Lock()
+	_, ok := s.sessions[key]
+	delete(s.sessions, key)
+	s.generations[key]++
+	s.mu.Unlock()
+
+	if !ok || s.backend == nil {
+		return nil
+	}
+	return s.backend.DeleteSession(key)
+}
+
+// RemoveMessages deletes every message of a session that matches. If a
+// removed message was covered by the session summary the summary is dropped,
+// since it may still repeat the removed content.
+func (s *SessionStore) RemoveMessages(sessionKey string, match func(Message) bool) (int, error) {
+	if s == nil {
+		return 0, nil
+	}
+
+	key := strings.TrimSpace(sessionKey)
+	defer s.lockKey(key)()
+
+	s.mu.Lock()
+	session, ok := s.sessions[key]
+	if !ok {
+		s.mu.Unlock()
+		return 0, nil
+	}
+
+	kept := session.Messages[:0]
+	removed := 0
+	for i, msg := range session.Messages {
+		if match(msg) {
+			removed++
+			if i < session.Summarized {
+				session.Summary = ""
+				session.Summarized = 0
+			}
+			continue
+		}
+		kept = append(kept, msg)
+	}
+	session.Messages = kept
+	if session.Summarized > len(kept) {
+		session.Summarized = len(kept)
+	}
+	empty := len(kept) == 0
+	if removed > 0 {
+		session.Updated = time.Now()
+		s.generations[key]++
+	}
+	s.mu.Unlock()
+
+	if removed == 0 {
+		return 0, nil
+	}
+	if empty {
+		return removed, s.deleteSession(key)
+	}
+	return removed, s.saveSession(key)
+}
+
+// SessionKeys returns the keys of all stored sessions.
+func (s *SessionStore) SessionKeys() []string {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.sessions))
+	for key := range s.sessions {
+		keys = append(keys, key)
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(keys)
+	return keys
+}
+
+// Prune applies the retention policy to every session and persists the result.
+func (s *SessionStore) Prune(policy RetentionPolicy, now time.Time) (PruneResult, error) {
+	result := PruneResult{}
+	if s == nil || !policy.Enabled() {
+		return result, nil
+	}
+
+	changed := make(map[string]struct{})
+	s.mu.Lock()
+	for key, session := range s.sessions {
+		drop := 0
+		if policy.MaxAge > 0 {
+			cutoff := now.Add(-policy.MaxAge)
+			for drop < len(session.Messages) && session.Messages[drop].Timestamp.Before(cutoff) {
+				drop++
+			}
+		}
+		if policy.MaxMessagesPerSession > 0 && len(session.Messages)-drop > policy.MaxMessagesPerSession {
+			drop = len(session.Messages) - policy.MaxMessagesPerSession
+		}
+		if drop > 0 {
+			dropOldest(session, drop)
+			result.RemovedMessages += drop
+			changed[key] = struct{}{}
+		}
+	}
+
+	if policy.MaxTotalBytes > 0 {
+		result.RemovedMessages += s.pruneToBytesLocked(policy.MaxTotalBytes, changed)
+	}
+	for key := range changed {
+		s.generations[key]++
+	}
+
+	empty := make([]string, 0)
+	for key := range changed {
+		if len(s.sessions[key].Messages) == 0 {
+			empty = append(empty, key)
+		}
+	}
+	s.mu.Unlock()
+
+	// Each session is written under its own lock. A message may have
+	// arrived since the pass above, so emptiness is checked again.
+	var firstErr error
+	for _, key := range empty {
+		delete(changed, key)
+		unlock := s.lockKey(key)
+		s.mu.RLock()
+		session, ok := s.sessions[key]
+		stillEmpty := ok && len(session.Messages) == 0
+		s.mu.RUnlock()
+
+		var err error
+		if stillEmpty {
+			err = s.deleteSession(key)
+			result.RemovedSessions++
+		} else {
+			err = s.saveSession(key)
+		}
+		unlock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for key := range changed {
+		unlock := s.lockKey(key)
+		err := s.saveSession(key)
+		unlock()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return result, firstErr
+}
+
+// pruneToBytesLocked drops the globally oldest messages until the approximate
+// stored size fits within limit.
+func (s *SessionStore) pruneToBytesLocked(limit int64, changed map[string]struct{}) int {
+	var total int64
+	for _, session := range s.sessions {
+		for _, msg := range session.Messages {
+			total += messageBytes(msg)
+		}
+	}
+	if total <= limit {
+		return 0
+	}
+
+	type ref struct {
+		key       string
+		timestamp time.Time
+		size      int64
+	}
+	refs := make([]ref, 0, 64)
+	for key, session := range s.sessions {
+		for _, msg := range session.Messages {
+			refs = append(refs, ref{key: key, timestamp: msg.Timestamp, size: messageBytes(msg)})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		return refs[i].timestamp.Before(refs[j].timestamp)
+	})
+
+	drops := make(map[string]int)
+	removed := 0
+	for _, item := range refs {
+		if total <= limit {
+			break
+		}
+		drops[item.key]++
+		total -= item.size
+		removed++
+	}
+	for key, count := range drops {
+		dropOldest(s.sessions[key], count)
+		changed[key] = struct{}{}
+	}
+	return removed
+}
+
+func dropOldest(session *Session, count int) {
+	if count > len(session.Messages) {
+		count = len(session.Messages)
+	}
+	session.Messages = append([]Message(nil), session.Messages[count:]...)
+	session.Summarized -= count
+	if session.Summarized < 0 {
+		session.Summarized = 0
+	}
+	session.Updated = time.Now()
+}
+
+func messageBytes(msg Message) int64 {
+	return int64(len(msg.Text) + len(msg.Channel) + len(msg.UserID) + len(msg.ChatID) + 64)
+}
+
+func sameMessage(a Message, b Message) bool {
+	return a.Channel == b.Channel &&
+		a.UserID == b.UserID &&
+		a.ChatID == b.ChatID &&
+		a.Text == b.Text &&
+		a.Timestamp.Equal(b.Timestamp)
+}
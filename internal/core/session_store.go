@@ -1,42 +1,89 @@
 package core
 
 import (
-	"encoding/json"
-	"os"
-	"path/filepath"
+	"errors"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
+// errStaleSummary is returned by SetSummary when the session changed while
+// its summary was being computed.
+var errStaleSummary = errors.New("session changed during compaction")
+
 type Session struct {
 	Key      string    `json:"key"`
 	Messages []Message `json:"messages"`
 	Created  time.Time `json:"created"`
 	Updated  time.Time `json:"updated"`
+	// Summary condenses Messages[:Summarized] into a rolling recap.
+	Summary    string `json:"summary,omitempty"`
+	Summarized int    `json:"summarized,omitempty"`
+}
+
+// SessionBackend persists sessions. SaveSession receives a full snapshot and
+// replaces whatever was stored for that key.
+type SessionBackend interface {
+	LoadSessions() ([]Session, error)
+	SaveSession(session Session) error
+	DeleteSession(key string) error
+	Close() error
+}
+
+// SessionAppender is implemented by backends that can persist one new message
+// without rewriting the whole session. The session passed in carries the
+// updated metadata but no messages.
+type SessionAppender interface {
+	AppendMessage(session Session, msg Message) error
+}
+
+// SessionMetaUpdater is implemented by backends that can store a session's
+// summary and timestamps without touching its messages. The session passed
+// in carries no messages.
+type SessionMetaUpdater interface {
+	UpdateSessionMeta(session Session) error
 }
 
 type SessionStore struct {
 	mu       sync.RWMutex
-	storage  string
+	backend  SessionBackend
 	sessions map[string]*Session
+	// keyLocks serialize each session's changes with their backend writes,
+	// so a full snapshot never lands between an in-memory append and its
+	// insert. Writes to different sessions do not wait for each other.
+	keysMu   sync.Mutex
+	keyLocks map[string]*sync.Mutex
+	// generations counts destructive changes per key (delete, removal,
+	// pruning). It outlives deleted sessions so a summary computed before
+	// the change can be recognised as stale.
+	generations map[string]uint64
 }
 
-func NewSessionStore(storage string) (*SessionStore, error) {
+// NewSessionStore loads all sessions from backend. A nil backend keeps
+// sessions in memory only.
+func NewSessionStore(backend SessionBackend) (*SessionStore, error) {
 	store := &SessionStore{
-		storage:  strings.TrimSpace(storage),
-		sessions: make(map[string]*Session),
+		backend:     backend,
+		sessions:    make(map[string]*Session),
+		generations: make(map[string]uint64),
+		keyLocks:    make(map[string]*sync.Mutex),
 	}
 
-	if store.storage == "" {
+	if backend == nil {
 		return store, nil
 	}
 
-	if err := os.MkdirAll(store.storage, 0o755); err != nil {
+	sessions, err := backend.LoadSessions()
+	if err != nil {
 		return nil, err
 	}
-	if err := store.loadSessions(); err != nil {
-		return nil, err
+	for i := range sessions {
+		session := sessions[i]
+		if strings.TrimSpace(session.Key) == "" {
+			continue
+		}
+		store.sessions[session.Key] = &session
 	}
 
 	return store, nil
@@ -52,6 +99,8 @@ func (s *SessionStore) AddMessage(sessionKey string, msg Message) error {
 		key = "global"
 	}
 
+	defer s.lockKey(key)()
+
 	s.mu.Lock()
 	session, exists := s.sessions[key]
 	if !exists {
@@ -65,9 +114,13 @@ func (s *SessionStore) AddMessage(sessionKey string, msg Message) error {
 	}
 	session.Messages = append(session.Messages, msg)
 	session.Updated = time.Now()
+	header := sessionHeader(session)
 	s.mu.Unlock()
 
-	return s.Save(key)
+	if appender, ok := s.backend.(SessionAppender); ok {
+		return appender.AppendMessage(header, msg)
+	}
+	return s.saveSession(key)
 }
 
 func (s *SessionStore) History(sessionKey string) []Message {
@@ -93,6 +146,138 @@ func (s *SessionStore) History(sessionKey string) []Message {
 	return history
 }
 
+// Summary returns the rolling summary of a session and how many of its
+// leading messages it covers.
+func (s *SessionStore) Summary(sessionKey string) (string, int) {
+	if s == nil {
+		return "", 0
+	}
+
+	key := strings.TrimSpace(sessionKey)
+	if key == "" {
+		key = "global"
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[key]
+	if !ok {
+		return "", 0
+	}
+	return session.Summary, session.Summarized
+}
+
+// Generation returns a counter that changes whenever messages of the session
+// are deleted, removed or pruned. Read it before the history a summary is
+// built from and pass it to SetSummary.
+func (s *SessionStore) Generation(sessionKey string) uint64 {
+	if s == nil {
+		return 0
+	}
+
+	key := strings.TrimSpace(sessionKey)
+	if key == "" {
+		key = "global"
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.generations[key]
+}
+
+// SetSummary stores a summary of the first summarized messages. It is
+// dropped if the session's generation no longer matches, since the messages
+// it covers may have been removed or shifted.
+func (s *SessionStore) SetSummary(sessionKey string, summary string, summarized int, generation uint64) error {
+	if s == nil {
+		return nil
+	}
+
+	key := strings.TrimSpace(sessionKey)
+	if key == "" {
+		key = "global"
+	}
+
+	defer s.lockKey(key)()
+
+	s.mu.Lock()
+	session, ok := s.sessions[key]
+	if !ok || s.generations[key] != generation {
+		s.mu.Unlock()
+		return errStaleSummary
+	}
+	if summarized > len(session.Messages) {
+		summarized = len(session.Messages)
+	}
+	session.Summary = strings.TrimSpace(summary)
+	session.Summarized = summarized
+	session.Updated = time.Now()
+	header := sessionHeader(session)
+	s.mu.Unlock()
+
+	if updater, ok := s.backend.(SessionMetaUpdater); ok {
+		return updater.UpdateSessionMeta(header)
+	}
+	return s.saveSession(key)
+}
+
+// SessionInfo describes a stored session without its messages.
+type SessionInfo struct {
+	Key        string    `json:"key"`
+	Messages   int       `json:"messages"`
+	Summarized int       `json:"summarized,omitempty"`
+	Created    time.Time `json:"created"`
+	Updated    time.Time `json:"updated"`
+}
+
+// Sessions lists the stored sessions, most recently updated first.
+func (s *SessionStore) Sessions() []SessionInfo {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.RLock()
+	infos := make([]SessionInfo, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		infos = append(infos, SessionInfo{
+			Key:        session.Key,
+			Messages:   len(session.Messages),
+			Summarized: session.Summarized,
+			Created:    session.Created,
+			Updated:    session.Updated,
+		})
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].Updated.Equal(infos[j].Updated) {
+			return infos[i].Updated.After(infos[j].Updated)
+		}
+		return infos[i].Key < infos[j].Key
+	})
+	return infos
+}
+
+// Session returns a copy of one stored session.
+func (s *SessionStore) Session(sessionKey string) (Session, bool) {
+	if s == nil {
+		return Session{}, false
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[strings.TrimSpace(sessionKey)]
+	if !ok {
+		return Session{}, false
+	}
+	snapshot := sessionHeader(session)
+	snapshot.Messages = make([]Message, len(session.Messages))
+	copy(snapshot.Messages, session.Messages)
+	return snapshot, true
+}
+
 func (s *SessionStore) SessionCount() int {
 	if s == nil {
 		return 0
@@ -118,14 +303,35 @@ func (s *SessionStore) TotalMessageCount() int {
 	return total
 }
 
+// Save writes a full snapshot of the session, replacing what the backend
+// stored for it.
 func (s *SessionStore) Save(sessionKey string) error {
-	if s == nil || s.storage == "" {
+	if s == nil {
 		return nil
 	}
 
-	filename := sanitizeSessionFilename(sessionKey)
-	if filename == "." || !filepath.IsLocal(filename) || strings.ContainsAny(filename, `/\`) {
-		return os.ErrInvalid
+	defer s.lockKey(sessionKey)()
+	return s.saveSession(sessionKey)
+}
+
+// lockKey takes the session's write lock and returns its unlock function.
+func (s *SessionStore) lockKey(key string) func() {
+	s.keysMu.Lock()
+	lock, ok := s.keyLocks[key]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.keyLocks[key] = lock
+	}
+	s.keysMu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+// saveSession is Save for callers that hold the session's write lock.
+func (s *SessionStore) saveSession(sessionKey string) error {
+	if s.backend == nil {
+		return nil
 	}
 
 	s.mu.RLock()
@@ -135,11 +341,7 @@ func (s *SessionStore) Save(sessionKey string) error {
 		return nil
 	}
 
-	snapshot := Session{
-		Key:     session.Key,
-		Created: session.Created,
-		Updated: session.Updated,
-	}
+	snapshot := sessionHeader(session)
 	if len(session.Messages) > 0 {
 		snapshot.Messages = make([]Message, len(session.Messages))
 		copy(snapshot.Messages, session.Messages)
@@ -148,82 +350,22 @@ func (s *SessionStore) Save(sessionKey string) error {
 	}
 	s.mu.RUnlock()
 
-	payload, err := json.MarshalIndent(snapshot, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	targetPath := filepath.Join(s.storage, filename+".json")
-	tempFile, err := os.CreateTemp(s.storage, "session-*.tmp")
-	if err != nil {
-		return err
-	}
-
-	tmpPath := tempFile.Name()
-	cleanup := true
-	defer func() {
-		if cleanup {
-			_ = os.Remove(tmpPath)
-		}
-	}()
-
-	if _, err := tempFile.Write(payload); err != nil {
-		_ = tempFile.Close()
-		return err
-	}
-	if err := tempFile.Chmod(0o644); err != nil {
-		_ = tempFile.Close()
-		return err
-	}
-	if err := tempFile.Sync(); err != nil {
-		_ = tempFile.Close()
-		return err
-	}
-	if err := tempFile.Close(); err != nil {
-		return err
-	}
-	if err := os.Rename(tmpPath, targetPath); err != nil {
-		return err
-	}
-
-	cleanup = false
-	return nil
+	return s.backend.SaveSession(snapshot)
 }
 
-func (s *SessionStore) loadSessions() error {
-	entries, err := os.ReadDir(s.storage)
-	if err != nil {
-		return err
-	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-		if filepath.Ext(entry.Name()) != ".json" {
-			continue
-		}
-
-		path := filepath.Join(s.storage, entry.Name())
-		payload, err := os.ReadFile(path)
-		if err != nil {
-			continue
-		}
-
-		var session Session
-		if err := json.Unmarshal(payload, &session); err != nil {
-			continue
-		}
-		if strings.TrimSpace(session.Key) == "" {
-			continue
-		}
-
-		s.sessions[session.Key] = &session
+func (s *SessionStore) Close() error {
+	if s == nil || s.backend == nil {
+		return nil
 	}
-
-	return nil
+	return s.backend.Close()
 }
 
-func sanitizeSessionFilename(key string) string {
-	return strings.ReplaceAll(strings.TrimSpace(key), ":", "_")
+func sessionHeader(session *Session) Session {
+	return Session{
+		Key:        session.Key,
+		Created:    session.Created,
+		Updated:    session.Updated,
+		Summary:    session.Summary,
+		Summarized: session.Summarized,
+	}
 }
@@ -0,0 +1,203 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tool is a capability the agent can offer to the LLM and to slash commands.
+// RequiredRole is the minimum caller role allowed to run it.
+type Tool interface {
+	Name() string
+	Definition() ToolDefinition
+	Available() bool
+	RequiredRole() Role
+	Execute(ctx context.Context, args map[string]any) (string, error)
+}
+
+// Command maps a slash command onto a registered tool call.
+// Resolve returns false when the arguments do not match the usage. The
+// resolved tool's own role is enforced in addition to Role.
+type Command struct {
+	Name    string
+	Usage   string
+	Role    Role
+	Resolve func(args []string) (ToolCall, bool)
+}
+
+type ToolRegistry struct {
+	mu       sync.RWMutex
+	tools    map[string]Tool
+	order    []string
+	commands map[string]Command
+}
+
+func NewToolRegistry() *ToolRegistry {
+	return &ToolRegistry{
+		tools:    make(map[string]Tool),
+		order:    make([]string, 0, 8),
+		commands: make(map[string]Command),
+	}
+}
+
+func (r *ToolRegistry) Register(tool Tool) error {
+	if tool == nil {
+		return fmt.Errorf("tool is nil")
+	}
+
+	name := strings.TrimSpace(tool.Name())
+	if name == "" {
+		return fmt.Errorf("tool name is required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tools[name]; exists {
+		return fmt.Errorf("tool %q is already registered", name)
+	}
+	r.tools[name] = tool
+	r.order = append(r.order, name)
+	return nil
+}
+
+func (r *ToolRegistry) RegisterCommand(cmd Command) error {
+	name := strings.ToLower(strings.TrimSpace(cmd.Name))
+	if !strings.HasPrefix(name, "/") || len(name) < 2 {
+		return fmt.Errorf("invalid command name %q", cmd.Name)
+	}
+	if cmd.Resolve == nil {
+		return fmt.Errorf("command %q has no resolver", name)
+	}
+	cmd.Name = name
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.commands[name]; exists {
+		return fmt.Errorf("command %q is already registered", name)
+	}
+	r.commands[name] = cmd
+	return nil
+}
+
+func (r *ToolRegistry) Lookup(name string) (Tool, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[strings.TrimSpace(name)]
+	return tool, ok
+}
+
+func (r *ToolRegistry) Command(name string) (Command, bool) {
+	if r == nil {
+		return Command{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, ok := r.commands[strings.ToLower(strings.TrimSpace(name))]
+	return cmd, ok
+}
+
+func (r *ToolRegistry) Commands() []Command {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	commands := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// Tools returns the currently available tools in registration order.
+func (r *ToolRegistry) Tools() []Tool {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tools := make([]Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tool := r.tools[name]
+		if tool.Available() {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+func (r *ToolRegistry) Definitions() []ToolDefinition {
+	tools := r.Tools()
+	definitions := make([]ToolDefinition, 0, len(tools))
+	for _, tool := range tools {
+		definitions = append(definitions, tool.Definition())
+	}
+	return definitions
+}
+
+func StringArgument(values map[string]any, key string) string {
+	raw, ok := values[key]
+	if !ok {
+		return ""
+	}
+	text, ok := raw.(string)
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(text)
+}
+
+func IntArgument(values map[string]any, key string) int {
+	raw, ok := values[key]
+	if !ok {
+		return 0
+	}
+
+	switch value := raw.(type) {
+	case int:
+		return value
+	case int64:
+		return int(value)
+	case float64:
+		return int(value)
+	case string:
+		return parseOptionalInt(value)
+	default:
+		return 0
+	}
+}
+
+func BoolArgument(values map[string]any, key string) bool {
+	raw, ok := values[key]
+	if !ok {
+		return false
+	}
+
+	switch value := raw.(type) {
+	case bool:
+		return value
+	case string:
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "1", "true", "yes":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,430 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const usageDayLayout = "2006-01-02"
+
+// usageFlushInterval batches writes of the usage file, which is rewritten in
+// full, so a busy chat does not rewrite it on every LLM response.
+const usageFlushInterval = 5 * time.Second
+
+// usageSystemUser owns LLM calls the agent makes on its own, such as
+// summarising sessions.
+const usageSystemUser = "system"
+
+// ModelPrice is what one million tokens cost. The unit is whatever currency
+// the prices are written in.
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// UsageBudget caps LLM use per local day. Zero disables a limit.
+type UsageBudget struct {
+	DailyTokens     int64
+	DailyCost       float64
+	UserDailyTokens int64
+	UserDailyCost   float64
+}
+
+// ErrBudgetExceeded is returned by CheckBudget once a daily budget is spent.
+var ErrBudgetExceeded = errors.New("daily llm budget exceeded")
+
+type UsageTotals struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	Cost             float64 `json:"cost"`
+}
+
+func (t UsageTotals) Tokens() int64 {
+	return t.PromptTokens + t.CompletionTokens
+}
+
+func (t *UsageTotals) add(other UsageTotals) {
+	t.Requests += other.Requests
+	t.PromptTokens += other.PromptTokens
+	t.CompletionTokens += other.CompletionTokens
+	t.Cost += other.Cost
+}
+
+// UsageRecord is the running total for one user, session and model on one
+// day.
+type UsageRecord struct {
+	Day     string `json:"day"`
+	User    string `json:"user"`
+	Session string `json:"session"`
+	Model   string `json:"model"`
+	UsageTotals
+}
+
+// UsageSummary aggregates one day of records.
+type UsageSummary struct {
+	Day       string                 `json:"day"`
+	Total     UsageTotals            `json:"total"`
+	ByModel   map[string]UsageTotals `json:"by_model,omitempty"`
+	ByUser    map[string]UsageTotals `json:"by_user,omitempty"`
+	BySession map[string]UsageTotals `json:"by_session,omitempty"`
+}
+
+type usageKey struct {
+	day     string
+	user    string
+	session string
+	model   string
+}
+
+type usageFile struct {
+	Records []UsageRecord `json:"records"`
+}
+
+// UsageTracker accumulates token counts and costs per day, user, session and
+// model. Updates are written to a JSON file at most every usageFlushInterval
+// and on Close.
+type UsageTracker struct {
+	mu         sync.Mutex
+	path       string
+	prices     map[string]ModelPrice
+	budget     UsageBudget
+	retainDays int
+	records    map[usageKey]*UsageTotals
+	pruned     string
+	dirty      bool
+	flushTimer *time.Timer
+	flushErr   error
+}
+
+func NewUsageTracker(path string, prices map[string]ModelPrice, budget UsageBudget, retainDays int) (*UsageTracker, error) {
+	if retainDays <= 0 {
+		retainDays = 90
+	}
+
+	tracker := &UsageTracker{
+		path:       strings.TrimSpace(path),
+		prices:     make(map[string]ModelPrice, len(prices)),
+		budget:     budget,
+		retainDays: retainDays,
+		records:    make(map[usageKey]*UsageTotals),
+	}
+	for model, price := range prices {
+		tracker.prices[strings.ToLower(strings.TrimSpace(model))] = price
+	}
+
+	if tracker.path != "" {
+		if err := tracker.load(); err != nil {
+			return nil, err
+		}
+	}
+	return tracker, nil
+}
+
+// Price returns the configured price for model, falling back to the "*"
+// entry.
+func (t *UsageTracker) Price(model string) (ModelPrice, bool) {
+	if price, ok := t.prices[strings.ToLower(strings.TrimSpace(model))]; ok {
+		return price, true
+	}
+	price, ok := t.prices["*"]
+	return price, ok
+}
+
+// Record adds one LLM response to the totals and returns its cost. The error
+// is that of an earlier background write that failed, if any.
+func (t *UsageTracker) Record(user string, session string, model string, promptTokens int, completionTokens int, at time.Time) (float64, error) {
+	if t == nil {
+		return 0, nil
+	}
+
+	usage := UsageTotals{
+		Requests:         1,
+		PromptTokens:     int64(max(promptTokens, 0)),
+		CompletionTokens: int64(max(completionTokens, 0)),
+	}
+	if price, ok := t.Price(model); ok {
+		usage.Cost = (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6
+	}
+
+	key := usageKey{
+		day:     at.Local().Format(usageDayLayout),
+		user:    usageName(user),
+		session: usageName(session),
+		model:   usageName(model),
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals, ok := t.records[key]
+	if !ok {
+		totals = &UsageTotals{}
+		t.records[key] = totals
+	}
+	totals.add(usage)
+	if t.pruned != key.day {
+		t.pruneLocked(at)
+		t.pruned = key.day
+	}
+
+	t.dirty = true
+	if t.flushTimer == nil && t.path != "" {
+		t.flushTimer = time.AfterFunc(usageFlushInterval, t.flush)
+	}
+	err := t.flushErr
+	t.flushErr = nil
+	return usage.Cost, err
+}
+
+// Close writes any pending updates to the usage file.
+func (t *UsageTracker) Close() error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.flushTimer != nil {
+		t.flushTimer.Stop()
+		t.flushTimer = nil
+	}
+	return t.saveDirtyLocked()
+}
+
+func (t *UsageTracker) flush() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.flushTimer = nil
+	if err := t.saveDirtyLocked(); err != nil {
+		t.flushErr = err
+	}
+}
+
+func (t *UsageTracker) saveDirtyLocked() error {
+	if !t.dirty {
+		return nil
+	}
+	if err := t.saveLocked(); err != nil {
+		return err
+	}
+	t.dirty = false
+	return nil
+}
+
+// Summary aggregates the records of day, formatted as YYYY-MM-DD in local
+// time. An empty day means today.
+func (t *UsageTracker) Summary(day string) UsageSummary {
+	if strings.TrimSpace(day) == "" {
+		day = time.Now().Format(usageDayLayout)
+	}
+	summary := UsageSummary{
+		Day:       day,
+		ByModel:   make(map[string]UsageTotals),
+		ByUser:    make(map[string]UsageTotals),
+		BySession: make(map[string]UsageTotals),
+	}
+	if t == nil {
+		return summary
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, totals := range t.records {
+		if key.day != day {
+			continue
+		}
+		summary.Total.add(*totals)
+		addTo(summary.ByModel, key.model, *totals)
+		addTo(summary.ByUser, key.user, *totals)
+		addTo(summary.BySession, key.session, *totals)
+	}
+	return summary
+}
+
+// UserTotals returns what user has used on the day of at.
+func (t *UsageTracker) UserTotals(user string, at time.Time) UsageTotals {
+	if t == nil {
+		return UsageTotals{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, userTotals := t.dayTotalsLocked(usageName(user), at.Local().Format(usageDayLayout))
+	return userTotals
+}
+
+// CheckBudget reports ErrBudgetExceeded when the global or the user's daily
+// budget has been spent.
+func (t *UsageTracker) CheckBudget(user string, at time.Time) error {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	total, userTotals := t.dayTotalsLocked(usageName(user), at.Local().Format(usageDayLayout))
+	switch {
+	case t.budget.DailyTokens > 0 && total.Tokens() >= t.budget.DailyTokens:
+		return fmt.Errorf("%w: %d of %d tokens used today", ErrBudgetExceeded, total.Tokens(), t.budget.DailyTokens)
+	case t.budget.DailyCost > 0 && total.Cost >= t.budget.DailyCost:
+		return fmt.Errorf("%w: %.4f of %.4f spent today", ErrBudgetExceeded, total.Cost, t.budget.DailyCost)
+	case t.budget.UserDailyTokens > 0 && userTotals.Tokens() >= t.budget.UserDailyTokens:
+		return fmt.Errorf("%w: you used %d of %d tokens today", ErrBudgetExceeded, userTotals.Tokens(), t.budget.UserDailyTokens)
+	case t.budget.UserDailyCost > 0 && userTotals.Cost >= t.budget.UserDailyCost:
+		return fmt.Errorf("%w: you spent %.4f of %.4f today", ErrBudgetExceeded, userTotals.Cost, t.budget.UserDailyCost)
+	}
+	return nil
+}
+
+func (t *UsageTracker) dayTotalsLocked(user string, day string) (UsageTotals, UsageTotals) {
+	var total, userTotals UsageTotals
+	for key, totals := range t.records {
+		if key.day != day {
+			continue
+		}
+		total.add(*totals)
+		if key.user == user {
+			userTotals.add(*totals)
+		}
+	}
+	return total, userTotals
+}
+
+func (t *UsageTracker) pruneLocked(now time.Time) {
+	cutoff := now.Local().AddDate(0, 0, -t.retainDays).Format(usageDayLayout)
+	for key := range t.records {
+		if key.day < cutoff {
+			delete(t.records, key)
+		}
+	}
+}
+
+func (t *UsageTracker) load() error {
+	payload, err := os.ReadFile(t.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("read usage: %w", err)
+	}
+
+	var decoded usageFile
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return fmt.Errorf("parse usage: %w", err)
+	}
+	for _, record := range decoded.Records {
+		key := usageKey{day: record.Day, user: record.User, session: record.Session, model: record.Model}
+		totals, ok := t.records[key]
+		if !ok {
+			totals = &UsageTotals{}
+			t.records[key] = totals
+		}
+		totals.add(record.UsageTotals)
+	}
+	return nil
+}
+
+func (t *UsageTracker) saveLocked() error {
+	if t.path == "" {
+		return nil
+	}
+
+	records := make([]UsageRecord, 0, len(t.records))
+	for key, totals := range t.records {
+		records = append(records, UsageRecord{
+			Day:         key.day,
+			User:        key.user,
+			Session:     key.session,
+			Model:       key.model,
+			UsageTotals: *totals,
+		})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		if a.User != b.User {
+			return a.User < b.User
+		}
+		if a.Session != b.Session {
+			return a.Session < b.Session
+		}
+		return a.Model < b.Model
+	})
+
+	payload, err := json.MarshalIndent(usageFile{Records: records}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(t.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create usage directory: %w", err)
+	}
+	tempFile, err := os.CreateTemp(dir, "usage-*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temp usage file: %w", err)
+	}
+	tempPath := tempFile.Name()
+
+	if _, err := tempFile.Write(payload); err != nil {
+		_ = tempFile.Close()
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("write usage: %w", err)
+	}
+	if err := tempFile.Close(); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("close usage: %w", err)
+	}
+	if err := os.Rename(tempPath, t.path); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("replace usage: %w", err)
+	}
+	return nil
+}
+
+func addTo(totals map[string]UsageTotals, name string, usage UsageTotals) {
+	current := totals[name]
+	current.add(usage)
+	totals[name] = current
+}
+
+func usageName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "unknown"
+	}
+	return name
+}
+
+// usageUser attributes usage to the person when the account is linked to an
+// identity, so budgets apply across channels.
+func (a *Agent) usageUser(msg Message) string {
+	if identity, ok := a.resolveIdentity(msg); ok {
+		return identity
+	}
+	return NormalizeAccount(msg.Channel, msg.UserID)
+}
+
+func (a *Agent) recordUsage(user string, sessionKey string, response LLMResponse) {
+	if a.usage == nil {
+		return
+	}
+	if _, err := a.usage.Record(user, sessionKey, response.Model, response.PromptTokens, response.CompletionTokens, time.Now()); err != nil {
+		a.logger.Warn("failed to record llm usage", "error", err)
+	}
+}
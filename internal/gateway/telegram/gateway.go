@@ -17,10 +17,12 @@ import (
 )
 
 type Gateway struct {
-	bot       *bot.Bot
-	logger    *slog.Logger
-	processor core.Processor
-	allowList map[int64]struct{}
+	bot            *bot.Bot
+	logger         *slog.Logger
+	processor      core.Processor
+	allowList      map[int64]struct{}
+	stream         bool
+	streamInterval time.Duration
 }
 
 func New(cfg config.TelegramConfig, processor core.Processor, logger *slog.Logger) (*Gateway, error) {
@@ -32,9 +34,11 @@ func New(cfg config.TelegramConfig, processor core.Processor, logger *slog.Logge
 	}
 
 	gateway := &Gateway{
-		logger:    logger,
-		processor: processor,
-		allowList: make(map[int64]struct{}, len(cfg.AllowList)),
+		logger:         logger,
+		processor:      processor,
+		allowList:      make(map[int64]struct{}, len(cfg.AllowList)),
+		stream:         cfg.StreamReplies,
+		streamInterval: time.Duration(cfg.StreamEditIntervalMS) * time.Millisecond,
 	}
 	for _, id := range cfg.AllowList {
 		gateway.allowList[id] = struct{}{}
@@ -56,8 +60,31 @@ func (g *Gateway) Start(ctx context.Context) error {
 	return nil
 }
 
+// Send delivers text to a chat the bot already talks to, such as the output
+// of a scheduled job.
+func (g *Gateway) Send(ctx context.Context, chatID string, text string) error {
+	id, err := strconv.ParseInt(strings.TrimSpace(chatID), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid telegram chat id %q", chatID)
+	}
+	if _, err := g.bot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID: id,
+		Text:   clip(text),
+	}); err != nil {
+		return fmt.Errorf("send telegram message: %w", err)
+	}
+	return nil
+}
+
 func (g *Gateway) handleUpdate(ctx context.Context, _ *bot.Bot, update *models.Update) {
-	if update == nil || update.Message == nil || update.Message.From == nil {
+	if update == nil {
+		return
+	}
+	if update.CallbackQuery != nil {
+		g.handleCallback(ctx, update.CallbackQuery)
+		return
+	}
+	if update.Message == nil || update.Message.From == nil {
 		return
 	}
 
@@ -76,29 +103,102 @@ func (g *Gateway) handleUpdate(ctx context.Context, _ *bot.Bot, update *models.U
 		return
 	}
 
-	reply, err := g.processor.Process(ctx, core.Message{
+	g.respond(ctx, userID, update.Message.Chat.ID, text)
+}
+
+// handleCallback runs the command carried by an inline button, such as the
+// confirmation button attached to pending actions.
+func (g *Gateway) handleCallback(ctx context.Context, query *models.CallbackQuery) {
+	_, _ = g.bot.AnswerCallbackQuery(ctx, &bot.AnswerCallbackQueryParams{CallbackQueryID: query.ID})
+
+	userID := query.From.ID
+	if !g.isAllowed(userID) {
+		g.logger.Warn("telegram user rejected by allow list", "user_id", userID)
+		return
+	}
+	if query.Message.Message == nil || !strings.HasPrefix(query.Data, core.ConfirmCommand+" ") {
+		return
+	}
+
+	chatID := query.Message.Message.Chat.ID
+	_, _ = g.bot.EditMessageReplyMarkup(ctx, &bot.EditMessageReplyMarkupParams{
+		ChatID:    chatID,
+		MessageID: query.Message.Message.ID,
+	})
+	g.respond(ctx, userID, chatID, query.Data)
+}
+
+func (g *Gateway) respond(ctx context.Context, userID int64, chatID int64, text string) {
+	msg := core.Message{
 		Channel:   "telegram",
 		UserID:    strconv.FormatInt(userID, 10),
-		ChatID:    strconv.FormatInt(update.Message.Chat.ID, 10),
+		ChatID:    strconv.FormatInt(chatID, 10),
 		Text:      text,
 		Timestamp: time.Now(),
-	})
+	}
+
+	var stream *streamReply
+	var reply string
+	var err error
+	if streamer, ok := g.processor.(core.StreamProcessor); ok && g.stream {
+		stream = g.newStreamReply(ctx, chatID)
+		reply, err = streamer.ProcessStream(ctx, msg, stream.update)
+	} else {
+		reply, err = g.processor.Process(ctx, msg)
+	}
 	if err != nil {
 		g.logger.Error("telegram processing error", "error", err, "user_id", userID)
 		reply = "Request failed."
 	}
 	if strings.TrimSpace(reply) == "" {
+		if stream != nil {
+			stream.discard()
+		}
 		return
 	}
 
-	if _, err := g.bot.SendMessage(ctx, &bot.SendMessageParams{
-		ChatID: update.Message.Chat.ID,
-		Text:   reply,
-	}); err != nil {
+	var markup models.ReplyMarkup
+	if code := confirmationCode(reply); code != "" {
+		markup = &models.InlineKeyboardMarkup{
+			InlineKeyboard: [][]models.InlineKeyboardButton{{
+				{Text: "Confirm", CallbackData: core.ConfirmCommand + " " + code},
+			}},
+		}
+	}
+	if stream != nil && stream.finish(reply, markup) {
+		return
+	}
+
+	params := &bot.SendMessageParams{
+		ChatID:      chatID,
+		Text:        reply,
+		ReplyMarkup: markup,
+	}
+	if _, err := g.bot.SendMessage(ctx, params); err != nil {
 		g.logger.Error("telegram send error", "error", err, "user_id", userID)
 	}
 }
 
+// confirmationCode extracts the code from a "/confirm <code>" instruction in
+// an agent reply.
+func confirmationCode(reply string) string {
+	_, rest, ok := strings.Cut(reply, core.ConfirmCommand+" ")
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return ""
+	}
+	code := fields[0]
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return ""
+		}
+	}
+	return code
+}
+
 func (g *Gateway) isAllowed(userID int64) bool {
 	if len(g.allowList) == 0 {
 		return false
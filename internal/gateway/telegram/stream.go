@@ -0,0 +1,155 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+// maxMessageChars keeps edits under Telegram's 4096 character limit.
+const maxMessageChars = 4000
+
+// streamReply shows a streaming LLM reply by sending one message and editing
+// it as more text arrives, at most once per interval.
+type streamReply struct {
+	gateway  *Gateway
+	ctx      context.Context
+	chatID   int64
+	interval time.Duration
+
+	mu        sync.Mutex
+	messageID int
+	shown     string
+	lastPush  time.Time
+	busy      bool
+	pending   sync.WaitGroup
+}
+
+func (g *Gateway) newStreamReply(ctx context.Context, chatID int64) *streamReply {
+	return &streamReply{
+		gateway:  g,
+		ctx:      ctx,
+		chatID:   chatID,
+		interval: g.streamInterval,
+	}
+}
+
+// update is called from the LLM stream. It never blocks on the network; an
+// update that arrives while an edit is in flight or too soon is dropped, and
+// finish always shows the complete text.
+func (s *streamReply) update(text string) {
+	text = clip(text)
+
+	s.mu.Lock()
+	if s.busy || text == "" || text == s.shown || time.Since(s.lastPush) < s.interval {
+		s.mu.Unlock()
+		return
+	}
+	s.busy = true
+	s.lastPush = time.Now()
+	s.mu.Unlock()
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		s.push(text, nil)
+
+		s.mu.Lock()
+		s.busy = false
+		s.mu.Unlock()
+	}()
+}
+
+// finish waits for any in-flight edit and then shows the final reply.
+// It reports false when nothing was streamed, so the caller sends normally.
+func (s *streamReply) finish(text string, markup models.ReplyMarkup) bool {
+	s.pending.Wait()
+
+	s.mu.Lock()
+	started := s.messageID != 0
+	s.mu.Unlock()
+	if !started {
+		return false
+	}
+
+	s.push(clip(text), markup)
+	return true
+}
+
+// discard waits for any in-flight edit and deletes the streamed message, for
+// replies that end without final text.
+func (s *streamReply) discard() {
+	s.pending.Wait()
+
+	s.mu.Lock()
+	messageID := s.messageID
+	s.messageID = 0
+	s.shown = ""
+	s.mu.Unlock()
+	if messageID == 0 {
+		return
+	}
+
+	if _, err := s.gateway.bot.DeleteMessage(s.ctx, &bot.DeleteMessageParams{
+		ChatID:    s.chatID,
+		MessageID: messageID,
+	}); err != nil {
+		s.gateway.logger.Warn("telegram delete error", "error", err, "chat_id", s.chatID)
+	}
+}
+
+func (s *streamReply) push(text string, markup models.ReplyMarkup) {
+	s.mu.Lock()
+	messageID := s.messageID
+	unchanged := text == s.shown
+	s.mu.Unlock()
+
+	if messageID == 0 {
+		message, err := s.gateway.bot.SendMessage(s.ctx, &bot.SendMessageParams{
+			ChatID:      s.chatID,
+			Text:        text,
+			ReplyMarkup: markup,
+		})
+		if err != nil {
+			s.gateway.logger.Error("telegram send error", "error", err, "chat_id", s.chatID)
+			return
+		}
+		s.mu.Lock()
+		s.messageID = message.ID
+		s.shown = text
+		s.mu.Unlock()
+		return
+	}
+
+	if unchanged && markup == nil {
+		return
+	}
+	if _, err := s.gateway.bot.EditMessageText(s.ctx, &bot.EditMessageTextParams{
+		ChatID:      s.chatID,
+		MessageID:   messageID,
+		Text:        text,
+		ReplyMarkup: markup,
+	}); err != nil {
+		s.gateway.logger.Warn("telegram edit error", "error", err, "chat_id", s.chatID)
+		return
+	}
+	s.mu.Lock()
+	s.shown = text
+	s.mu.Unlock()
+}
+
+func clip(text string) string {
+	if len(text) <= maxMessageChars {
+		return text
+	}
+	// Cut at a rune boundary so a multi-byte character is never split.
+	cut := maxMessageChars
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
@@ -12,6 +12,7 @@ import (
 	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/store/sqlstore"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	waLog "go.mau.fi/whatsmeow/util/log"
 	"google.golang.org/protobuf/proto"
@@ -20,11 +21,19 @@ import (
 	"clawkangsar/internal/core"
 )
 
+const (
+	groupPolicyIgnore  = "ignore"
+	groupPolicyMention = "mention"
+	groupPolicyAlways  = "always"
+)
+
 type Gateway struct {
-	client    *whatsmeow.Client
-	logger    *slog.Logger
-	processor core.Processor
-	qrCancel  context.CancelFunc
+	client      *whatsmeow.Client
+	logger      *slog.Logger
+	processor   core.Processor
+	qrCancel    context.CancelFunc
+	allowList   map[string]struct{}
+	groupPolicy string
 }
 
 func New(cfg config.WhatsAppConfig, processor core.Processor, logger *slog.Logger) (*Gateway, error) {
@@ -32,6 +41,24 @@ func New(cfg config.WhatsAppConfig, processor core.Processor, logger *slog.Logge
 		logger = slog.Default()
 	}
 
+	groupPolicy := strings.ToLower(strings.TrimSpace(cfg.GroupPolicy))
+	switch groupPolicy {
+	case "":
+		groupPolicy = groupPolicyIgnore
+	case groupPolicyIgnore, groupPolicyMention, groupPolicyAlways:
+	default:
+		return nil, fmt.Errorf("unsupported whatsapp.group_policy %q; use ignore, mention or always", cfg.GroupPolicy)
+	}
+
+	allowList := make(map[string]struct{}, len(cfg.AllowList))
+	for _, entry := range cfg.AllowList {
+		jid, err := normalizeAllowEntry(entry)
+		if err != nil {
+			return nil, fmt.Errorf("whatsapp.allow_list: %w", err)
+		}
+		allowList[jid] = struct{}{}
+	}
+
 	container, err := sqlstore.New(context.Background(), "sqlite3", cfg.SessionDSN, waLog.Stdout("WhatsAppDB", "WARN", false))
 	if err != nil {
 		return nil, fmt.Errorf("create whatsapp sql store: %w", err)
@@ -44,9 +71,11 @@ func New(cfg config.WhatsAppConfig, processor core.Processor, logger *slog.Logge
 
 	client := whatsmeow.NewClient(deviceStore, waLog.Stdout("WhatsApp", "WARN", false))
 	gateway := &Gateway{
-		client:    client,
-		logger:    logger,
-		processor: processor,
+		client:      client,
+		logger:      logger,
+		processor:   processor,
+		allowList:   allowList,
+		groupPolicy: groupPolicy,
 	}
 	client.AddEventHandler(gateway.handleEvent)
 
@@ -82,6 +111,23 @@ func (g *Gateway) Start(ctx context.Context) error {
 	return nil
 }
 
+// Send delivers text to a chat JID, such as the output of a scheduled job.
+func (g *Gateway) Send(ctx context.Context, chatID string, text string) error {
+	jid, err := types.ParseJID(strings.TrimSpace(chatID))
+	if err != nil {
+		return fmt.Errorf("invalid whatsapp chat %q: %w", chatID, err)
+	}
+	if !g.client.IsConnected() {
+		return fmt.Errorf("whatsapp is not connected")
+	}
+	if _, err := g.client.SendMessage(ctx, jid, &waProto.Message{
+		Conversation: proto.String(text),
+	}); err != nil {
+		return fmt.Errorf("send whatsapp message: %w", err)
+	}
+	return nil
+}
+
 func (g *Gateway) consumeQR(qrChannel <-chan whatsmeow.QRChannelItem) {
 	for evt := range qrChannel {
 		switch evt.Event {
@@ -108,9 +154,26 @@ func (g *Gateway) handleEvent(rawEvent interface{}) {
 		return
 	}
 
+	sender := senderJID(event.Info.MessageSource)
+	if event.Info.IsGroup && !g.shouldAnswerGroup(event.Message) {
+		return
+	}
+	if !g.isAllowed(event.Info.MessageSource) {
+		g.logger.Warn("whatsapp sender rejected by allow list", "sender", sender.String(), "chat", event.Info.Chat.String())
+		if event.Info.IsGroup {
+			return
+		}
+		if _, err := g.client.SendMessage(context.Background(), event.Info.Chat, &waProto.Message{
+			Conversation: proto.String("Unauthorized."),
+		}); err != nil {
+			g.logger.Error("whatsapp send error", "error", err, "chat", event.Info.Chat.String())
+		}
+		return
+	}
+
 	reply, err := g.processor.Process(context.Background(), core.Message{
 		Channel:   "whatsapp",
-		UserID:    event.Info.Sender.String(),
+		UserID:    sender.String(),
 		ChatID:    event.Info.Chat.String(),
 		Text:      text,
 		Timestamp: event.Info.Timestamp,
@@ -132,6 +195,85 @@ func (g *Gateway) handleEvent(rawEvent interface{}) {
 	}
 }
 
+func (g *Gateway) isAllowed(source types.MessageSource) bool {
+	if len(g.allowList) == 0 {
+		return false
+	}
+	for _, jid := range []types.JID{source.Sender, source.SenderAlt} {
+		if jid.IsEmpty() {
+			continue
+		}
+		if _, ok := g.allowList[jid.ToNonAD().String()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldAnswerGroup applies the group policy. In mention mode the bot answers
+// when it is @-mentioned or when someone replies to one of its messages.
+func (g *Gateway) shouldAnswerGroup(message *waProto.Message) bool {
+	switch g.groupPolicy {
+	case groupPolicyAlways:
+		return true
+	case groupPolicyMention:
+	default:
+		return false
+	}
+
+	own := make(map[string]struct{}, 2)
+	if g.client.Store.ID != nil {
+		own[g.client.Store.ID.ToNonAD().String()] = struct{}{}
+	}
+	if !g.client.Store.LID.IsEmpty() {
+		own[g.client.Store.LID.ToNonAD().String()] = struct{}{}
+	}
+
+	contextInfo := message.GetExtendedTextMessage().GetContextInfo()
+	if contextInfo == nil {
+		return false
+	}
+	for _, mentioned := range contextInfo.GetMentionedJID() {
+		if jid, err := types.ParseJID(mentioned); err == nil {
+			if _, ok := own[jid.ToNonAD().String()]; ok {
+				return true
+			}
+		}
+	}
+	if participant := contextInfo.GetParticipant(); participant != "" {
+		if jid, err := types.ParseJID(participant); err == nil {
+			if _, ok := own[jid.ToNonAD().String()]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// senderJID prefers the phone-number JID over a hidden LID so that allow-list
+// and access entries can be written as phone numbers.
+func senderJID(source types.MessageSource) types.JID {
+	if source.Sender.Server == types.HiddenUserServer && !source.SenderAlt.IsEmpty() {
+		return source.SenderAlt.ToNonAD()
+	}
+	return source.Sender.ToNonAD()
+}
+
+func normalizeAllowEntry(entry string) (string, error) {
+	value := strings.TrimPrefix(strings.TrimSpace(entry), "+")
+	if value == "" {
+		return "", fmt.Errorf("empty entry")
+	}
+	if !strings.Contains(value, "@") {
+		return types.NewJID(value, types.DefaultUserServer).String(), nil
+	}
+	jid, err := types.ParseJID(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid JID %q: %w", entry, err)
+	}
+	return jid.ToNonAD().String(), nil
+}
+
 func extractText(message *waProto.Message) string {
 	if message == nil {
 		return ""
@@ -0,0 +1,169 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNotFound is returned by Admin methods when the named item does not exist.
+var ErrNotFound = errors.New("not found")
+
+// MessageRequest is the body of POST /api/messages. The message is handled as
+// if UserID had sent it on Channel, so the access policy and rate limits of
+// that account apply. Channel defaults to "api" and ChatID to UserID.
+type MessageRequest struct {
+	Channel string `json:"channel"`
+	UserID  string `json:"user_id"`
+	ChatID  string `json:"chat_id"`
+	Text    string `json:"text"`
+}
+
+// ToolRequest is the body of POST /api/tools/{name}.
+type ToolRequest struct {
+	Arguments map[string]any `json:"arguments"`
+}
+
+// Admin is what the /api endpoints act on.
+type Admin interface {
+	Sessions() any
+	// Session returns ErrNotFound for an unknown key, as does DeleteSession.
+	Session(key string) (any, error)
+	DeleteSession(ctx context.Context, key string) (int, error)
+	SendMessage(ctx context.Context, req MessageRequest) (string, error)
+	Tools() any
+	// Usage returns the token usage of day, YYYY-MM-DD or empty for today.
+	Usage(day string) any
+	CallTool(ctx context.Context, name string, arguments map[string]any) (string, error)
+	// Reload re-reads the configuration and reports what it applied.
+	Reload(ctx context.Context) (any, error)
+	// KillBrowser reports whether a browser was running.
+	KillBrowser() bool
+}
+
+// APIHandler serves the admin API under /api. A request is accepted with an
+// "Authorization: Bearer <token>" header or, when the server uses TLS with a
+// client CA, a verified client certificate.
+func APIHandler(token string, admin Admin) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/sessions", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"sessions": admin.Sessions()})
+	})
+	mux.HandleFunc("GET /api/sessions/{key}", func(w http.ResponseWriter, r *http.Request) {
+		session, err := admin.Session(r.PathValue("key"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, session)
+	})
+	mux.HandleFunc("DELETE /api/sessions/{key}", func(w http.ResponseWriter, r *http.Request) {
+		removed, err := admin.DeleteSession(r.Context(), r.PathValue("key"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "deleted", "messages": removed})
+	})
+	mux.HandleFunc("POST /api/messages", func(w http.ResponseWriter, r *http.Request) {
+		var req MessageRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		req.Channel = strings.TrimSpace(req.Channel)
+		req.UserID = strings.TrimSpace(req.UserID)
+		req.ChatID = strings.TrimSpace(req.ChatID)
+		req.Text = strings.TrimSpace(req.Text)
+		switch {
+		case req.Text == "":
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "text is required"})
+			return
+		case req.UserID == "":
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "user_id is required"})
+			return
+		}
+		if req.Channel == "" {
+			req.Channel = "api"
+		}
+		if req.ChatID == "" {
+			req.ChatID = req.UserID
+		}
+
+		reply, err := admin.SendMessage(r.Context(), req)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"reply": reply})
+	})
+	mux.HandleFunc("GET /api/usage", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, admin.Usage(r.URL.Query().Get("day")))
+	})
+	mux.HandleFunc("GET /api/tools", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"tools": admin.Tools()})
+	})
+	mux.HandleFunc("POST /api/tools/{name}", func(w http.ResponseWriter, r *http.Request) {
+		var req ToolRequest
+		if r.ContentLength != 0 && !decodeBody(w, r, &req) {
+			return
+		}
+		if req.Arguments == nil {
+			req.Arguments = map[string]any{}
+		}
+
+		output, err := admin.CallTool(r.Context(), r.PathValue("name"), req.Arguments)
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"output": output})
+	})
+	mux.HandleFunc("POST /api/reload", func(w http.ResponseWriter, r *http.Request) {
+		result, err := admin.Reload(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	})
+	mux.HandleFunc("POST /api/browser/kill", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"killed": admin.KillBrowser()})
+	})
+
+	return RequireAuth(token, mux)
+}
+
+// RequireAuth accepts a request with an "Authorization: Bearer <token>"
+// header or a verified client certificate and rejects everything else.
+func RequireAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !verifiedClient(r) && !validBearer(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func verifiedClient(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, target any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(target); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body"})
+		return false
+	}
+	return true
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+}
@@ -0,0 +1,293 @@
+package health
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dashboardCookie = "clawkangsar_dashboard"
+	// loginFailureDelay slows down password guessing.
+	loginFailureDelay = time.Second
+	// Failed logins are counted per remote address and in total over
+	// loginFailureWindow; past either cap every login is refused until the
+	// window ends, so parallel guessing gains nothing.
+	loginFailureWindow     = 15 * time.Minute
+	maxLoginFailures       = 5
+	maxGlobalLoginFailures = 50
+)
+
+//go:embed web
+var webFiles embed.FS
+
+// DashboardOptions configures the web dashboard. Chat messages are sent
+// through Admin as the "web" channel with the logged-in username as the user.
+type DashboardOptions struct {
+	Username   string
+	Password   string
+	SessionTTL time.Duration
+	Snapshot   SnapshotFunc
+	Audit      AuditFunc
+	Admin      Admin
+	Logger     *slog.Logger
+}
+
+type dashboard struct {
+	opts   DashboardOptions
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	logins   map[string]time.Time
+	failures map[string]loginFailures
+	global   loginFailures
+}
+
+type loginFailures struct {
+	count int
+	since time.Time
+}
+
+// DashboardHandler serves the web UI under /dashboard/. Pages and data need a
+// login; a successful one sets a cookie that expires after SessionTTL.
+func DashboardHandler(opts DashboardOptions) http.Handler {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = 12 * time.Hour
+	}
+	d := &dashboard{
+		opts:     opts,
+		logger:   logger,
+		logins:   make(map[string]time.Time),
+		failures: make(map[string]loginFailures),
+	}
+
+	static, _ := fs.Sub(webFiles, "web")
+	mux := http.NewServeMux()
+	mux.Handle("GET /dashboard/static/", http.StripPrefix("/dashboard/static/", http.FileServerFS(static)))
+	mux.HandleFunc("GET /dashboard/{$}", func(w http.ResponseWriter, r *http.Request) {
+		page := "index.html"
+		if !d.loggedIn(r) {
+			page = "login.html"
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		http.ServeFileFS(w, r, static, page)
+	})
+	mux.HandleFunc("POST /dashboard/login", d.handleLogin)
+	mux.HandleFunc("POST /dashboard/logout", d.handleLogout)
+	mux.Handle("GET /dashboard/api/status", d.require(func(w http.ResponseWriter, _ *http.Request) {
+		payload := map[string]any{"status": "ok"}
+		if opts.Snapshot != nil {
+			payload["snapshot"] = opts.Snapshot()
+		}
+		writeJSON(w, http.StatusOK, payload)
+	}))
+	mux.Handle("GET /dashboard/api/audit", d.require(AuditHandler(opts.Audit).ServeHTTP))
+	mux.Handle("GET /dashboard/api/sessions", d.require(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"sessions": opts.Admin.Sessions()})
+	}))
+	mux.Handle("GET /dashboard/api/sessions/{key}", d.require(func(w http.ResponseWriter, r *http.Request) {
+		session, err := opts.Admin.Session(r.PathValue("key"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, session)
+	}))
+	mux.Handle("GET /dashboard/api/usage", d.require(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, opts.Admin.Usage(r.URL.Query().Get("day")))
+	}))
+	mux.Handle("POST /dashboard/api/chat", d.require(d.handleChat))
+	return mux
+}
+
+func (d *dashboard) handleLogin(w http.ResponseWriter, r *http.Request) {
+	remote := remoteHost(r)
+	if !d.loginAllowed(remote, time.Now()) {
+		d.logger.Warn("dashboard login refused after repeated failures", "remote", r.RemoteAddr)
+		http.Redirect(w, r, "/dashboard/?locked=1", http.StatusSeeOther)
+		return
+	}
+
+	username := r.PostFormValue("username")
+	password := r.PostFormValue("password")
+	if d.opts.Password == "" || !equalStrings(username, d.opts.Username) || !equalStrings(password, d.opts.Password) {
+		d.logger.Warn("dashboard login failed", "username", username, "remote", r.RemoteAddr)
+		d.recordLoginFailure(remote, time.Now())
+		time.Sleep(loginFailureDelay)
+		http.Redirect(w, r, "/dashboard/?failed=1", http.StatusSeeOther)
+		return
+	}
+
+	token, err := newLoginToken()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	d.mu.Lock()
+	for existing, expires := range d.logins {
+		if now.After(expires) {
+			delete(d.logins, existing)
+		}
+	}
+	d.logins[token] = now.Add(d.opts.SessionTTL)
+	delete(d.failures, remote)
+	d.mu.Unlock()
+
+	d.logger.Info("dashboard login", "username", username, "remote", r.RemoteAddr)
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie,
+		Value:    token,
+		Path:     "/dashboard/",
+		MaxAge:   int(d.opts.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/dashboard/", http.StatusSeeOther)
+}
+
+// loginAllowed reports whether the address, and the dashboard as a whole,
+// are still under their failed-login caps.
+func (d *dashboard) loginAllowed(remote string, now time.Time) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if now.Sub(d.global.since) > loginFailureWindow {
+		d.global = loginFailures{}
+	}
+	failed := d.failures[remote]
+	if now.Sub(failed.since) > loginFailureWindow {
+		delete(d.failures, remote)
+		failed = loginFailures{}
+	}
+	return failed.count < maxLoginFailures && d.global.count < maxGlobalLoginFailures
+}
+
+func (d *dashboard) recordLoginFailure(remote string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, failed := range d.failures {
+		if now.Sub(failed.since) > loginFailureWindow {
+			delete(d.failures, key)
+		}
+	}
+	d.failures[remote] = countFailure(d.failures[remote], now)
+	d.global = countFailure(d.global, now)
+}
+
+func countFailure(failed loginFailures, now time.Time) loginFailures {
+	if failed.count == 0 {
+		failed.since = now
+	}
+	failed.count++
+	return failed
+}
+
+// remoteHost is the client address without its port.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func (d *dashboard) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(dashboardCookie); err == nil {
+		d.mu.Lock()
+		delete(d.logins, cookie.Value)
+		d.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     dashboardCookie,
+		Path:     "/dashboard/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/dashboard/", http.StatusSeeOther)
+}
+
+func (d *dashboard) handleChat(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Text = strings.TrimSpace(req.Text); req.Text == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "text is required"})
+		return
+	}
+
+	reply, err := d.opts.Admin.SendMessage(r.Context(), MessageRequest{
+		Channel: "web",
+		UserID:  d.opts.Username,
+		ChatID:  d.opts.Username,
+		Text:    req.Text,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"reply": reply})
+}
+
+// require rejects requests without a valid login. The page's script also
+// sets X-Requested-With, which another site cannot send without a CORS
+// preflight that is never allowed.
+func (d *dashboard) require(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !d.loggedIn(r) || r.Header.Get("X-Requested-With") != "clawkangsar" {
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "login required"})
+			return
+		}
+		next(w, r)
+	})
+}
+
+func (d *dashboard) loggedIn(r *http.Request) bool {
+	cookie, err := r.Cookie(dashboardCookie)
+	if err != nil {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	expires, ok := d.logins[cookie.Value]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expires) {
+		delete(d.logins, cookie.Value)
+		return false
+	}
+	return true
+}
+
+func newLoginToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func equalStrings(provided string, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
@@ -0,0 +1,109 @@
+package health
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Exposition builds a response in the Prometheus text format. Declare each
+// metric with Family before writing its samples.
+type Exposition struct {
+	buf bytes.Buffer
+}
+
+// Family writes the HELP and TYPE lines. kind is counter, gauge or histogram.
+func (e *Exposition) Family(name string, kind string, help string) {
+	fmt.Fprintf(&e.buf, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, kind)
+}
+
+// Sample writes one value. labels are key, value pairs.
+func (e *Exposition) Sample(name string, value float64, labels ...string) {
+	e.buf.WriteString(name)
+	e.buf.WriteString(formatLabels(labels))
+	e.buf.WriteByte(' ')
+	e.buf.WriteString(formatValue(value))
+	e.buf.WriteByte('\n')
+}
+
+// Histogram writes the bucket, sum and count samples of one histogram.
+// counts holds one non-cumulative count per bound plus one for +Inf.
+func (e *Exposition) Histogram(name string, bounds []float64, counts []uint64, sum float64, count uint64, labels ...string) {
+	var cumulative uint64
+	for i, bound := range bounds {
+		if i < len(counts) {
+			cumulative += counts[i]
+		}
+		e.Sample(name+"_bucket", float64(cumulative), append(labels, "le", formatValue(bound))...)
+	}
+	e.Sample(name+"_bucket", float64(count), append(labels, "le", "+Inf")...)
+	e.Sample(name+"_sum", sum, labels...)
+	e.Sample(name+"_count", float64(count), labels...)
+}
+
+// Bool returns 1 for true, for gauges such as "running".
+func Bool(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// MetricsHandler serves the metrics written by collect on each scrape.
+func MetricsHandler(collect func(e *Exposition)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		var e Exposition
+		collect(&e)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(e.buf.Bytes())
+	})
+}
+
+// SortedKeys returns map keys in order, so series are written in a stable
+// order between scrapes.
+func SortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatLabels(labels []string) string {
+	if len(labels) < 2 {
+		return ""
+	}
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, labels[i]+`="`+escapeLabel(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func escapeHelp(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
@@ -2,22 +2,47 @@ package health
 
 import (
 	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 type SnapshotFunc func() map[string]any
 type ReadyFunc func() bool
 
+// AuditFunc returns up to limit recent audit entries, newest first.
+type AuditFunc func(limit int) (any, error)
+
+// NotifyRequest is the body accepted by NotifyHandler. Set either User, an
+// identity or "<channel>:<user id>" account, or both Channel and ChatID.
+type NotifyRequest struct {
+	User    string `json:"user"`
+	Channel string `json:"channel"`
+	ChatID  string `json:"chat_id"`
+	Text    string `json:"text"`
+}
+
+// NotifyFunc delivers a notification and returns the channel it went out on.
+type NotifyFunc func(ctx context.Context, req NotifyRequest) (string, error)
+
 type Server struct {
 	addr     string
 	logger   *slog.Logger
 	snapshot SnapshotFunc
 	ready    ReadyFunc
+	routes   map[string]http.Handler
+	certFile string
+	keyFile  string
+	tls      *tls.Config
 }
 
 func NewServer(host string, port int, snapshot SnapshotFunc, ready ReadyFunc, logger *slog.Logger) *Server {
@@ -29,7 +54,40 @@ func NewServer(host string, port int, snapshot SnapshotFunc, ready ReadyFunc, lo
 		logger:   logger,
 		snapshot: snapshot,
 		ready:    ready,
+		routes:   make(map[string]http.Handler),
+	}
+}
+
+// Handle registers an additional endpoint. It must be called before Start.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.routes[pattern] = handler
+}
+
+// UseTLS serves HTTPS with the given certificate. With clientCAFile, clients
+// may present a certificate signed by that CA; whether one was verified is
+// left to the handlers. It must be called before Start.
+func (s *Server) UseTLS(certFile string, keyFile string, clientCAFile string) error {
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return fmt.Errorf("load health TLS certificate: %w", err)
 	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		payload, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return fmt.Errorf("read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(payload) {
+			return fmt.Errorf("client CA file %s has no PEM certificates", clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	s.certFile = certFile
+	s.keyFile = keyFile
+	s.tls = config
+	return nil
 }
 
 func (s *Server) Start(ctx context.Context) error {
@@ -37,11 +95,15 @@ func (s *Server) Start(ctx context.Context) error {
 	mux.HandleFunc("/health", s.handleHealth)
 	mux.HandleFunc("/ready", s.handleReady)
 	mux.HandleFunc("/status", s.handleStatus)
+	for pattern, handler := range s.routes {
+		mux.Handle(pattern, handler)
+	}
 
 	server := &http.Server{
 		Addr:              s.addr,
 		Handler:           mux,
 		ReadHeaderTimeout: 5 * time.Second,
+		TLSConfig:         s.tls,
 	}
 
 	go func() {
@@ -51,8 +113,13 @@ func (s *Server) Start(ctx context.Context) error {
 		_ = server.Shutdown(shutdownCtx)
 	}()
 
-	s.logger.Info("health server started", "addr", s.addr)
-	err := server.ListenAndServe()
+	s.logger.Info("health server started", "addr", s.addr, "tls", s.tls != nil)
+	var err error
+	if s.tls != nil {
+		err = server.ListenAndServeTLS(s.certFile, s.keyFile)
+	} else {
+		err = server.ListenAndServe()
+	}
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("health server listen failed: %w", err)
 	}
@@ -90,6 +157,79 @@ func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
 	writeJSON(w, http.StatusOK, payload)
 }
 
+// AuditHandler serves recent audit entries. The optional limit query
+// parameter defaults to 50 and is capped at 500.
+func AuditHandler(recent AuditFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := 50
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			value, err := strconv.Atoi(raw)
+			if err != nil || value <= 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": "limit must be a positive integer"})
+				return
+			}
+			limit = min(value, 500)
+		}
+
+		entries, err := recent(limit)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"entries": entries})
+	})
+}
+
+// NotifyHandler lets local scripts push a message through the gateways with
+// POST and an "Authorization: Bearer <token>" header.
+func NotifyHandler(token string, notify NotifyFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "use POST"})
+			return
+		}
+		if !validBearer(r, token) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+
+		var req NotifyRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid JSON body"})
+			return
+		}
+		req.Text = strings.TrimSpace(req.Text)
+		switch {
+		case req.Text == "":
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "text is required"})
+			return
+		case strings.TrimSpace(req.User) == "" && (strings.TrimSpace(req.Channel) == "" || strings.TrimSpace(req.ChatID) == ""):
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "set user, or channel and chat_id"})
+			return
+		}
+
+		channel, err := notify(r.Context(), req)
+		if err != nil {
+			writeJSON(w, http.StatusBadGateway, map[string]any{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "sent", "channel": channel})
+	})
+}
+
+func validBearer(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), []byte(token)) == 1
+}
+
 func writeJSON(w http.ResponseWriter, statusCode int, payload any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
@@ -0,0 +1,212 @@
+"use strict";
+
+const refreshMs = 10000;
+let openSession = "";
+
+async function api(path, options = {}) {
+  const response = await fetch("/dashboard/api/" + path, {
+    ...options,
+    headers: { "X-Requested-With": "clawkangsar", "Content-Type": "application/json", ...options.headers },
+    credentials: "same-origin",
+  });
+  if (response.status === 401) {
+    location.reload();
+    throw new Error("login required");
+  }
+  const payload = await response.json();
+  if (!response.ok) {
+    throw new Error(payload.error || response.statusText);
+  }
+  return payload;
+}
+
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined && text !== null) node.textContent = String(text);
+  if (className) node.className = className;
+  return node;
+}
+
+function formatTime(value) {
+  if (!value || value.startsWith("0001-")) return "";
+  return new Date(value).toLocaleString();
+}
+
+function formatDuration(seconds) {
+  const days = Math.floor(seconds / 86400);
+  const hours = Math.floor((seconds % 86400) / 3600);
+  const minutes = Math.floor((seconds % 3600) / 60);
+  return (days ? days + "d " : "") + hours + "h " + minutes + "m";
+}
+
+function fillList(id, entries) {
+  const list = document.getElementById(id);
+  list.replaceChildren();
+  for (const [label, value] of entries) {
+    list.append(el("dt", label), el("dd", value));
+  }
+}
+
+function fillTable(id, rows) {
+  const body = document.querySelector("#" + id + " tbody");
+  body.replaceChildren(...rows);
+}
+
+function row(cells) {
+  const tr = el("tr");
+  for (const cell of cells) {
+    if (cell instanceof Node) {
+      const td = el("td");
+      td.append(cell);
+      tr.append(td);
+    } else {
+      tr.append(el("td", cell));
+    }
+  }
+  return tr;
+}
+
+async function loadStatus() {
+  const { snapshot } = await api("status");
+  document.getElementById("version").textContent = snapshot.version || "";
+  fillList("overview", [
+    ["Ready", snapshot.ready ? "yes" : "no"],
+    ["Uptime", formatDuration(snapshot.uptime_seconds || 0)],
+    ["Started", formatTime(snapshot.started_at)],
+  ]);
+
+  const gateways = Object.entries(snapshot.gateways || {}).sort(([a], [b]) => a.localeCompare(b));
+  fillTable("gateways", gateways.map(([name, state]) => row([
+    name,
+    el("span", state.running ? "running" : "stopped", state.running ? "ok" : "error"),
+    formatTime(state.last_change),
+    state.last_error || "",
+  ])));
+
+  const agent = snapshot.agent || {};
+  const entries = [
+    ["Memory messages", agent.in_memory_messages],
+    ["Stored sessions", agent.stored_sessions],
+    ["Stored messages", agent.stored_messages],
+  ];
+  for (const [channel, count] of Object.entries(agent.messages || {})) {
+    entries.push(["Messages (" + channel + ")", count]);
+  }
+  for (const provider of agent.llm_providers || []) {
+    entries.push(["LLM " + provider.name, provider.answered + " answered, " + provider.failures + " failed"]);
+  }
+  if (agent.usage) {
+    const total = agent.usage.total;
+    entries.push(["Tokens today", total.prompt_tokens + total.completion_tokens]);
+    entries.push(["Cost today", total.cost.toFixed(4)]);
+  }
+  fillList("agent", entries);
+
+  const browser = snapshot.browser;
+  fillList("browser", browser ? [
+    ["Active", browser.active ? "yes" : "no"],
+    ["Last used", formatTime(browser.last_used)],
+    ["Launches", browser.launches],
+    ["Idle timeout", browser.idle_timeout_seconds + "s"],
+  ] : [["Browser", "not configured"]]);
+}
+
+async function loadUsage() {
+  const usage = await api("usage");
+  const users = Object.entries(usage.by_user || {}).sort(([a], [b]) => a.localeCompare(b));
+  fillTable("usage", users.map(([user, totals]) => row([
+    user,
+    totals.requests,
+    totals.prompt_tokens + totals.completion_tokens,
+    totals.cost.toFixed(4),
+  ])));
+}
+
+async function loadSessions() {
+  const { sessions } = await api("sessions");
+  fillTable("sessions", (sessions || []).map((session) => {
+    const tr = row([session.key, session.messages, formatTime(session.updated)]);
+    tr.className = "clickable";
+    tr.addEventListener("click", () => showSession(session.key));
+    return tr;
+  }));
+  if (openSession) {
+    await showSession(openSession);
+  }
+}
+
+async function showSession(key) {
+  openSession = key;
+  const title = document.getElementById("transcript-title");
+  const transcript = document.getElementById("transcript");
+  title.hidden = false;
+  transcript.hidden = false;
+  title.textContent = key;
+  try {
+    const session = await api("sessions/" + encodeURIComponent(key));
+    transcript.replaceChildren();
+    if (session.summary) {
+      transcript.append(message("summary", "", session.summary));
+    }
+    for (const item of session.messages || []) {
+      transcript.append(message(item.Channel + " " + (item.UserID || ""), item.Timestamp, item.Text));
+    }
+    transcript.scrollTop = transcript.scrollHeight;
+  } catch (err) {
+    transcript.replaceChildren(el("p", err.message, "error"));
+  }
+}
+
+function message(who, time, text) {
+  const block = el("div", null, "message");
+  block.append(el("div", who + (time ? " · " + formatTime(time) : ""), "meta"), el("div", text));
+  return block;
+}
+
+async function loadAudit() {
+  const { entries } = await api("audit?limit=25");
+  fillTable("audit", (entries || []).map((entry) => row([
+    formatTime(entry.time),
+    entry.tool,
+    entry.origin,
+    entry.channel + ":" + entry.user_id,
+    el("span", entry.status + (entry.error ? ": " + entry.error : ""), entry.status === "ok" ? "ok" : "error"),
+    entry.duration_ms + " ms",
+  ])));
+}
+
+async function refresh() {
+  for (const load of [loadStatus, loadUsage, loadSessions, loadAudit]) {
+    try {
+      await load();
+    } catch (err) {
+      console.error(err);
+    }
+  }
+}
+
+document.getElementById("chat-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const input = document.getElementById("chat-text");
+  const log = document.getElementById("chat-log");
+  const text = input.value.trim();
+  if (!text) return;
+
+  input.value = "";
+  input.disabled = true;
+  log.append(message("you", new Date().toISOString(), text));
+  log.scrollTop = log.scrollHeight;
+  try {
+    const { reply } = await api("chat", { method: "POST", body: JSON.stringify({ text }) });
+    log.append(message("ClawKangsar", new Date().toISOString(), reply || "(no reply)"));
+  } catch (err) {
+    log.append(el("p", err.message, "error"));
+  } finally {
+    input.disabled = false;
+    input.focus();
+    log.scrollTop = log.scrollHeight;
+  }
+});
+
+refresh();
+setInterval(refresh, refreshMs);
@@ -0,0 +1,295 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"clawkangsar/internal/config"
+	"clawkangsar/internal/core"
+)
+
+const (
+	anthropicBaseURL    = "https://api.anthropic.com"
+	anthropicAPIVersion = "2023-06-01"
+	anthropicAPIKeyEnv  = "ANTHROPIC_API_KEY"
+)
+
+// AnthropicProvider talks to the Anthropic Messages API with native
+// tool_use and tool_result blocks.
+type AnthropicProvider struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature float64
+	maxTokens   int
+	timeout     time.Duration
+	client      *http.Client
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	Temperature float64            `json:"temperature,omitempty"`
+	MaxTokens   int                `json:"max_tokens"`
+}
+
+type anthropicMessage struct {
+	Role    string           `json:"role"`
+	Content []anthropicBlock `json:"content"`
+}
+
+type anthropicBlock struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Input     any    `json:"input,omitempty"`
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+func NewAnthropicProvider(cfg config.LLMConfig) (*AnthropicProvider, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if strings.TrimSpace(cfg.Model) == "" {
+		return nil, errors.New("llm.model is required when llm.enabled=true")
+	}
+
+	apiKey := strings.TrimSpace(cfg.APIKey)
+	if apiKey == "" && strings.TrimSpace(cfg.APIKeyEnv) != "" {
+		apiKey = strings.TrimSpace(os.Getenv(cfg.APIKeyEnv))
+	}
+	if apiKey == "" {
+		apiKey = strings.TrimSpace(os.Getenv(anthropicAPIKeyEnv))
+	}
+	if apiKey == "" {
+		return nil, errors.New("llm.api_key, llm.api_key_env or ANTHROPIC_API_KEY is required for anthropic")
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	maxTokens := cfg.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 1024
+	}
+
+	return &AnthropicProvider{
+		baseURL:     strings.TrimSuffix(providerBaseURL(cfg.BaseURL, anthropicBaseURL), "/v1"),
+		apiKey:      apiKey,
+		model:       strings.TrimSpace(cfg.Model),
+		temperature: cfg.Temperature,
+		maxTokens:   maxTokens,
+		timeout:     timeout,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}, nil
+}
+
+func (p *AnthropicProvider) EstimateTokens(msg core.LLMMessage) int {
+	return core.EstimateTokens(msg, 4)
+}
+
+func (p *AnthropicProvider) Complete(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+) (core.LLMResponse, error) {
+	system, translated := translateAnthropicMessages(messages)
+	reqBody := anthropicRequest{
+		Model:       p.model,
+		System:      system,
+		Messages:    translated,
+		Temperature: p.temperature,
+		MaxTokens:   p.maxTokens,
+	}
+	if len(tools) > 0 {
+		reqBody.Tools = translateAnthropicTools(tools)
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("marshal llm request: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, p.baseURL+"/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("build llm request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("send llm request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("read llm response: %w", err)
+	}
+
+	var decoded anthropicResponse
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
+		}
+		return core.LLMResponse{}, fmt.Errorf("parse llm response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if decoded.Error != nil && decoded.Error.Message != "" {
+			return core.LLMResponse{}, newStatusError(resp, "llm request failed: "+decoded.Error.Message)
+		}
+		return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
+	}
+	if decoded.Error != nil && decoded.Error.Message != "" {
+		return core.LLMResponse{}, fmt.Errorf("llm error: %s", decoded.Error.Message)
+	}
+
+	result := core.LLMResponse{
+		Model:            p.model,
+		PromptTokens:     decoded.Usage.InputTokens,
+		CompletionTokens: decoded.Usage.OutputTokens,
+	}
+	textParts := make([]string, 0, 1)
+	for _, block := range decoded.Content {
+		switch block.Type {
+		case "text":
+			if strings.TrimSpace(block.Text) != "" {
+				textParts = append(textParts, block.Text)
+			}
+		case "tool_use":
+			args, ok := block.Input.(map[string]any)
+			if !ok {
+				args = make(map[string]any)
+			}
+			result.ToolCalls = append(result.ToolCalls, core.ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: args,
+			})
+		}
+	}
+	result.Content = strings.TrimSpace(strings.Join(textParts, "\n"))
+	return result, nil
+}
+
+// translateAnthropicMessages moves system messages into the top-level system
+// prompt, turns tool results into user tool_result blocks, and merges
+// consecutive turns of the same role as the API expects.
+func translateAnthropicMessages(messages []core.LLMMessage) (string, []anthropicMessage) {
+	systemParts := make([]string, 0, 2)
+	out := make([]anthropicMessage, 0, len(messages))
+
+	appendBlocks := func(role string, blocks ...anthropicBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if last := len(out) - 1; last >= 0 && out[last].Role == role {
+			out[last].Content = append(out[last].Content, blocks...)
+			return
+		}
+		out = append(out, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, item := range messages {
+		content := strings.TrimSpace(item.Content)
+		switch item.Role {
+		case "system":
+			if content != "" {
+				systemParts = append(systemParts, content)
+			}
+		case "assistant":
+			blocks := make([]anthropicBlock, 0, 1+len(item.ToolCalls))
+			if content != "" {
+				blocks = append(blocks, anthropicBlock{Type: "text", Text: content})
+			}
+			for _, call := range item.ToolCalls {
+				input := call.Arguments
+				if input == nil {
+					input = make(map[string]any)
+				}
+				blocks = append(blocks, anthropicBlock{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Name,
+					Input: input,
+				})
+			}
+			appendBlocks("assistant", blocks...)
+		case "tool":
+			if item.ToolCallID == "" {
+				if content != "" {
+					appendBlocks("user", anthropicBlock{Type: "text", Text: content})
+				}
+				continue
+			}
+			appendBlocks("user", anthropicBlock{
+				Type:      "tool_result",
+				ToolUseID: item.ToolCallID,
+				Content:   item.Content,
+			})
+		default:
+			if content != "" {
+				appendBlocks("user", anthropicBlock{Type: "text", Text: content})
+			}
+		}
+	}
+
+	return strings.Join(systemParts, "\n\n"), out
+}
+
+func translateAnthropicTools(tools []core.ToolDefinition) []anthropicTool {
+	out := make([]anthropicTool, 0, len(tools))
+	for _, tool := range tools {
+		schema := tool.Parameters
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		out = append(out, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: schema,
+		})
+	}
+	return out
+}
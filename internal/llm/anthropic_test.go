@@ -0,0 +1,146 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"clawkangsar/internal/config"
+	"clawkangsar/internal/core"
+)
+
+func newTestAnthropic(t *testing.T, handler http.HandlerFunc) *AnthropicProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	provider, err := NewAnthropicProvider(config.LLMConfig{
+		Enabled:  true,
+		Provider: "anthropic",
+		BaseURL:  server.URL,
+		APIKey:   "test-key",
+		Model:    "test-model",
+	})
+	if err != nil {
+		t.Fatalf("NewAnthropicProvider: %v", err)
+	}
+	return provider
+}
+
+func TestAnthropicRequestTranslation(t *testing.T) {
+	var got anthropicRequest
+	provider := newTestAnthropic(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %q, want /v1/messages", r.URL.Path)
+		}
+		if key := r.Header.Get("x-api-key"); key != "test-key" {
+			t.Errorf("x-api-key = %q", key)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"done"}],"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":2}}`))
+	})
+
+	messages := []core.LLMMessage{
+		{Role: "system", Content: "You are a test."},
+		{Role: "system", Content: "Summary: earlier chat."},
+		{Role: "user", Content: "first"},
+		{Role: "user", Content: "second"},
+		{Role: "assistant", Content: "Checking.", ToolCalls: []core.ToolCall{
+			{ID: "call_1", Name: "web_fetch", Arguments: map[string]any{"url": "https://example.com"}},
+		}},
+		{Role: "tool", ToolCallID: "call_1", Content: "page text"},
+		{Role: "user", Content: "thanks"},
+	}
+	response, err := provider.Complete(context.Background(), messages, nil)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if response.Content != "done" || response.PromptTokens != 10 || response.CompletionTokens != 2 {
+		t.Errorf("response = %+v", response)
+	}
+
+	if got.System != "You are a test.\n\nSummary: earlier chat." {
+		t.Errorf("system = %q", got.System)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("got %d messages, want 3: %+v", len(got.Messages), got.Messages)
+	}
+
+	first := got.Messages[0]
+	if first.Role != "user" || len(first.Content) != 2 || first.Content[0].Text != "first" || first.Content[1].Text != "second" {
+		t.Errorf("consecutive user turns not merged: %+v", first)
+	}
+
+	assistant := got.Messages[1]
+	if assistant.Role != "assistant" || len(assistant.Content) != 2 {
+		t.Fatalf("assistant turn = %+v", assistant)
+	}
+	use := assistant.Content[1]
+	input, _ := use.Input.(map[string]any)
+	if use.Type != "tool_use" || use.ID != "call_1" || use.Name != "web_fetch" || input["url"] != "https://example.com" {
+		t.Errorf("tool_use block = %+v", use)
+	}
+
+	last := got.Messages[2]
+	if last.Role != "user" || len(last.Content) != 2 {
+		t.Fatalf("tool result turn = %+v", last)
+	}
+	if result := last.Content[0]; result.Type != "tool_result" || result.ToolUseID != "call_1" || result.Content != "page text" {
+		t.Errorf("tool_result block = %+v", result)
+	}
+	if text := last.Content[1]; text.Type != "text" || text.Text != "thanks" {
+		t.Errorf("user text after tool result = %+v", text)
+	}
+}
+
+func TestAnthropicToolUseResponse(t *testing.T) {
+	provider := newTestAnthropic(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"Let me look."},{"type":"tool_use","id":"toolu_1","name":"system_metrics","input":{"verbose":true}}],"stop_reason":"tool_use"}`))
+	})
+
+	response, err := provider.Complete(context.Background(), []core.LLMMessage{{Role: "user", Content: "how is the pi?"}}, []core.ToolDefinition{
+		{Name: "system_metrics", Description: "Read metrics."},
+	})
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if response.Content != "Let me look." {
+		t.Errorf("content = %q", response.Content)
+	}
+	if len(response.ToolCalls) != 1 {
+		t.Fatalf("got %d tool calls, want 1", len(response.ToolCalls))
+	}
+	call := response.ToolCalls[0]
+	if call.ID != "toolu_1" || call.Name != "system_metrics" || call.Arguments["verbose"] != true {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestAnthropicStatusError(t *testing.T) {
+	provider := newTestAnthropic(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+	})
+
+	_, err := provider.Complete(context.Background(), []core.LLMMessage{{Role: "user", Content: "hi"}}, nil)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("error = %v, want *StatusError", err)
+	}
+	if statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("status = %d", statusErr.StatusCode)
+	}
+	if statusErr.RetryAfter != 7*time.Second {
+		t.Errorf("retry after = %s, want 7s", statusErr.RetryAfter)
+	}
+	if statusErr.Message != "llm request failed: slow down" {
+		t.Errorf("message = %q", statusErr.Message)
+	}
+}
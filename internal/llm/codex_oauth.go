@@ -33,6 +33,7 @@ type CodexOAuthProvider struct {
 
 type codexResponseEvent struct {
 	Type     string `json:"type"`
+	Delta    string `json:"delta"`
 	Response struct {
 		Status string `json:"status"`
 		Output []struct {
@@ -46,6 +47,10 @@ type codexResponseEvent struct {
 				Text string `json:"text"`
 			} `json:"content"`
 		} `json:"output"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
 	} `json:"response"`
 }
 
@@ -86,10 +91,24 @@ func NewCodexOAuthProvider(cfg config.LLMConfig) (*CodexOAuthProvider, error) {
 	}, nil
 }
 
+func (p *CodexOAuthProvider) EstimateTokens(msg core.LLMMessage) int {
+	return core.EstimateTokens(msg, 4)
+}
+
 func (p *CodexOAuthProvider) Complete(
 	ctx context.Context,
 	messages []core.LLMMessage,
 	tools []core.ToolDefinition,
+) (core.LLMResponse, error) {
+	return p.CompleteStream(ctx, messages, tools, nil)
+}
+
+// CompleteStream forwards output_text deltas from the SSE stream to onText.
+func (p *CodexOAuthProvider) CompleteStream(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+	onText core.PartialFunc,
 ) (core.LLMResponse, error) {
 	cred, err := auth.LoadCodexCredential(p.codexAuthPath)
 	if err != nil {
@@ -127,10 +146,15 @@ func (p *CodexOAuthProvider) Complete(
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return core.LLMResponse{}, fmt.Errorf("codex request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("codex request failed with status %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
 	}
 
-	return parseCodexStream(resp.Body)
+	result, err := parseCodexStream(resp.Body, onText)
+	if err != nil {
+		return core.LLMResponse{}, err
+	}
+	result.Model = p.model
+	return result, nil
 }
 
 func (p *CodexOAuthProvider) buildRequest(messages []core.LLMMessage, tools []core.ToolDefinition) map[string]any {
@@ -211,10 +235,12 @@ func translateCodexTools(tools []core.ToolDefinition) []map[string]any {
 	return out
 }
 
-func parseCodexStream(body io.Reader) (core.LLMResponse, error) {
+func parseCodexStream(body io.Reader, onText core.PartialFunc) (core.LLMResponse, error) {
 	scanner := bufio.NewScanner(body)
 	scanner.Buffer(make([]byte, 0, 16*1024), 1024*1024)
 
+	var partial strings.Builder
+
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if !strings.HasPrefix(line, "data: ") {
@@ -233,11 +259,19 @@ func parseCodexStream(body io.Reader) (core.LLMResponse, error) {
 		if err := json.Unmarshal([]byte(payload), &event); err != nil {
 			continue
 		}
+		if event.Type == "response.output_text.delta" && onText != nil && event.Delta != "" {
+			partial.WriteString(event.Delta)
+			onText(partial.String())
+			continue
+		}
 		if event.Type != "response.completed" {
 			continue
 		}
 
-		result := core.LLMResponse{}
+		result := core.LLMResponse{
+			PromptTokens:     event.Response.Usage.InputTokens,
+			CompletionTokens: event.Response.Usage.OutputTokens,
+		}
 		for _, item := range event.Response.Output {
 			switch item.Type {
 			case "message":
@@ -0,0 +1,301 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"clawkangsar/internal/config"
+	"clawkangsar/internal/core"
+)
+
+const maxRetryDelay = 30 * time.Second
+
+// NewProvider builds the provider named by cfg.Provider.
+func NewProvider(cfg config.LLMConfig) (core.ChatProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Provider)) {
+	case "openai_compat", "openai-compatible", "openai":
+		return NewOpenAICompatProvider(cfg)
+	case "codex_oauth", "openai_oauth", "codex":
+		return NewCodexOAuthProvider(cfg)
+	case "anthropic", "claude":
+		return NewAnthropicProvider(cfg)
+	case "ollama":
+		return NewOllamaProvider(cfg)
+	case "llamacpp", "llama.cpp", "llama_cpp":
+		return NewLlamaCppProvider(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported llm provider %q", cfg.Provider)
+	}
+}
+
+type fallbackEntry struct {
+	name     string
+	provider core.ChatProvider
+	stats    core.ProviderStats
+}
+
+// FallbackProvider tries an ordered chain of providers. Transient failures
+// are retried with exponential backoff, honouring Retry-After, before the
+// next provider in the chain is tried.
+type FallbackProvider struct {
+	mu         sync.Mutex
+	logger     *slog.Logger
+	entries    []*fallbackEntry
+	maxRetries int
+	backoff    time.Duration
+	sleep      func(ctx context.Context, delay time.Duration) error
+}
+
+// NewFallbackProvider builds every provider in cfg.Chain().
+func NewFallbackProvider(cfg config.LLMConfig, logger *slog.Logger) (*FallbackProvider, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	// A model name only makes sense to the provider it was written for, so a
+	// fallback on another provider must name its own.
+	for i, fallback := range cfg.Fallbacks {
+		provider := strings.TrimSpace(fallback.Provider)
+		if provider == "" || strings.EqualFold(provider, cfg.Provider) {
+			continue
+		}
+		if strings.TrimSpace(fallback.Model) == "" {
+			return nil, fmt.Errorf("llm.fallbacks[%d]: model is required when the provider differs from llm.provider", i)
+		}
+	}
+
+	chain := cfg.Chain()
+	entries := make([]*fallbackEntry, 0, len(chain))
+	for i, entryCfg := range chain {
+		provider, err := NewProvider(entryCfg)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("llm.fallbacks[%d]: %w", i-1, err)
+		}
+		name := strings.ToLower(strings.TrimSpace(entryCfg.Provider))
+		if model := strings.TrimSpace(entryCfg.Model); model != "" {
+			name += "/" + model
+		}
+		entries = append(entries, &fallbackEntry{
+			name:     name,
+			provider: provider,
+			stats:    core.ProviderStats{Name: name},
+		})
+	}
+
+	backoff := time.Duration(cfg.RetryBackoffMS) * time.Millisecond
+	if backoff <= 0 {
+		backoff = 500 * time.Millisecond
+	}
+
+	return &FallbackProvider{
+		logger:     logger,
+		entries:    entries,
+		maxRetries: cfg.MaxRetries,
+		backoff:    backoff,
+		sleep:      sleepContext,
+	}, nil
+}
+
+func (p *FallbackProvider) Complete(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+) (core.LLMResponse, error) {
+	return p.CompleteStream(ctx, messages, tools, nil)
+}
+
+// CompleteStream streams from providers that support it; the others answer
+// in one piece.
+func (p *FallbackProvider) CompleteStream(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+	onText core.PartialFunc,
+) (core.LLMResponse, error) {
+	var lastErr error
+	for i, entry := range p.entries {
+		response, err := p.completeWithRetry(ctx, entry, messages, tools, onText)
+		if err == nil {
+			if i > 0 {
+				p.logger.Info("llm answered by fallback provider", "provider", entry.name)
+			}
+			return response, nil
+		}
+		if ctx.Err() != nil {
+			return core.LLMResponse{}, err
+		}
+
+		lastErr = err
+		if i+1 < len(p.entries) {
+			p.logger.Warn("llm provider failed; trying next", "provider", entry.name, "next", p.entries[i+1].name, "error", err)
+		}
+	}
+	return core.LLMResponse{}, lastErr
+}
+
+func (p *FallbackProvider) completeWithRetry(
+	ctx context.Context,
+	entry *fallbackEntry,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+	onText core.PartialFunc,
+) (core.LLMResponse, error) {
+	streamer, streaming := entry.provider.(core.StreamingProvider)
+	for attempt := 0; ; attempt++ {
+		var response core.LLMResponse
+		var err error
+		started := time.Now()
+		if streaming && onText != nil {
+			response, err = streamer.CompleteStream(ctx, messages, tools, onText)
+		} else {
+			response, err = entry.provider.Complete(ctx, messages, tools)
+		}
+		p.record(entry, err, time.Since(started))
+		if err == nil {
+			return response, nil
+		}
+		if ctx.Err() != nil || attempt >= p.maxRetries {
+			return core.LLMResponse{}, err
+		}
+
+		transient, retryAfter := isTransient(err)
+		if !transient {
+			return core.LLMResponse{}, err
+		}
+
+		delay := p.backoff << attempt
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+		if retryAfter > 0 {
+			if retryAfter > maxRetryDelay {
+				// Waiting that long would stall the chat; let the next
+				// provider answer instead.
+				return core.LLMResponse{}, err
+			}
+			delay = retryAfter
+		}
+
+		p.logger.Warn("llm request failed; retrying", "provider", entry.name, "attempt", attempt+1, "delay", delay, "error", err)
+		if err := p.sleep(ctx, delay); err != nil {
+			return core.LLMResponse{}, err
+		}
+	}
+}
+
+func (p *FallbackProvider) record(entry *fallbackEntry, err error, elapsed time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entry.stats.Attempts++
+	entry.stats.Latency.Observe(elapsed.Seconds())
+	if err != nil {
+		entry.stats.Failures++
+		entry.stats.LastError = err.Error()
+		return
+	}
+	entry.stats.Answered++
+	entry.stats.LastAnswered = time.Now()
+}
+
+func (p *FallbackProvider) ProviderStats() []core.ProviderStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make([]core.ProviderStats, 0, len(p.entries))
+	for _, entry := range p.entries {
+		entryStats := entry.stats
+		entryStats.Latency = entry.stats.Latency.Clone()
+		stats = append(stats, entryStats)
+	}
+	return stats
+}
+
+// EstimateTokens uses the primary provider's estimate so the context window
+// is sized for the model that normally answers.
+func (p *FallbackProvider) EstimateTokens(msg core.LLMMessage) int {
+	if estimator, ok := p.entries[0].provider.(core.TokenEstimator); ok {
+		return estimator.EstimateTokens(msg)
+	}
+	return core.EstimateTokens(msg, 4)
+}
+
+// CheckModel passes when at least one provider in the chain has its model
+// available. Failures of the others are only logged.
+func (p *FallbackProvider) CheckModel(ctx context.Context) error {
+	var firstErr error
+	usable := 0
+	for _, entry := range p.entries {
+		checker, ok := entry.provider.(ModelChecker)
+		if !ok {
+			usable++
+			continue
+		}
+		if err := checker.CheckModel(ctx); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			if len(p.entries) > 1 {
+				p.logger.Warn("llm provider model unavailable", "provider", entry.name, "error", err)
+			}
+			continue
+		}
+		usable++
+	}
+	if usable == 0 {
+		return firstErr
+	}
+	return nil
+}
+
+// isTransient reports whether err is worth retrying on the same provider and
+// any delay the server asked for.
+func isTransient(err error) (bool, time.Duration) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusRequestTimeout,
+			http.StatusTooEarly,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+			529:
+			return true, statusErr.RetryAfter
+		}
+		return false, 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true, 0
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, 0
+	}
+	return false, 0
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
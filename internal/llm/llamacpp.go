@@ -0,0 +1,368 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"clawkangsar/internal/config"
+	"clawkangsar/internal/core"
+)
+
+const (
+	llamaCppBaseURL = "http://127.0.0.1:8080"
+
+	llamaCppToolCallOpen  = "<tool_call>"
+	llamaCppToolCallClose = "</tool_call>"
+)
+
+// LlamaCppProvider talks to llama-server through its native endpoints:
+// /apply-template renders the conversation and tools with the model's own
+// chat template, and /completion generates from that prompt. Unlike the
+// OpenAI layer, /completion reports when the prompt was cut to fit the
+// server's context. Tool calls are read from <tool_call> blocks, the format
+// of the Qwen and Hermes templates, and need the server to run with --jinja.
+// The server serves one model, so the API key and model name are optional.
+type LlamaCppProvider struct {
+	serverURL   string
+	apiKey      string
+	model       string
+	temperature float64
+	maxTokens   int
+	timeout     time.Duration
+	client      *http.Client
+}
+
+type llamaCppTemplateRequest struct {
+	Messages []openAICompatMessage `json:"messages"`
+	Tools    []openAICompatTool    `json:"tools,omitempty"`
+}
+
+type llamaCppCompletionRequest struct {
+	Prompt      string   `json:"prompt"`
+	NPredict    int      `json:"n_predict"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	Stream      bool     `json:"stream"`
+	CachePrompt bool     `json:"cache_prompt"`
+}
+
+type llamaCppCompletionResponse struct {
+	Content         string `json:"content"`
+	Stop            bool   `json:"stop"`
+	StopType        string `json:"stop_type"`
+	Truncated       bool   `json:"truncated"`
+	Model           string `json:"model"`
+	TokensEvaluated int    `json:"tokens_evaluated"`
+	TokensPredicted int    `json:"tokens_predicted"`
+}
+
+type llamaCppErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func NewLlamaCppProvider(cfg config.LLMConfig) (*LlamaCppProvider, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	serverURL := strings.TrimSuffix(providerBaseURL(cfg.BaseURL, llamaCppBaseURL), "/v1")
+
+	apiKey := strings.TrimSpace(cfg.APIKey)
+	if apiKey == "" && strings.TrimSpace(cfg.APIKeyEnv) != "" && cfg.APIKeyEnv != "OPENAI_API_KEY" {
+		apiKey = strings.TrimSpace(os.Getenv(cfg.APIKeyEnv))
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	return &LlamaCppProvider{
+		serverURL:   serverURL,
+		apiKey:      apiKey,
+		model:       strings.TrimSpace(cfg.Model),
+		temperature: cfg.Temperature,
+		maxTokens:   cfg.MaxTokens,
+		timeout:     timeout,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}, nil
+}
+
+func (p *LlamaCppProvider) EstimateTokens(msg core.LLMMessage) int {
+	return core.EstimateTokens(msg, 4)
+}
+
+// CheckModel asks the server's /health endpoint whether the model has
+// finished loading.
+func (p *LlamaCppProvider) CheckModel(ctx context.Context) error {
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, p.serverURL+"/health", nil)
+	if err != nil {
+		return fmt.Errorf("build llama.cpp health request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("llama.cpp server is not reachable at %s: %w", p.serverURL, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusServiceUnavailable:
+		return fmt.Errorf("llama.cpp server at %s is still loading its model", p.serverURL)
+	default:
+		var decoded llamaCppErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&decoded) == nil && decoded.Error.Message != "" {
+			return fmt.Errorf("llama.cpp server is not ready: %s", decoded.Error.Message)
+		}
+		return fmt.Errorf("llama.cpp health check failed with status %d", resp.StatusCode)
+	}
+}
+
+func (p *LlamaCppProvider) Complete(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+) (core.LLMResponse, error) {
+	resp, cancel, err := p.send(ctx, messages, tools, false)
+	if err != nil {
+		return core.LLMResponse{}, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	var decoded llamaCppCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return core.LLMResponse{}, fmt.Errorf("parse llama.cpp response: %w", err)
+	}
+	return p.result(decoded)
+}
+
+// CompleteStream reads the /completion event stream. Text from an opening
+// <tool_call> tag on is held back from onText.
+func (p *LlamaCppProvider) CompleteStream(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+	onText core.PartialFunc,
+) (core.LLMResponse, error) {
+	resp, cancel, err := p.send(ctx, messages, tools, true)
+	if err != nil {
+		return core.LLMResponse{}, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var final llamaCppCompletionResponse
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 16*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+		var errorChunk llamaCppErrorResponse
+		if json.Unmarshal([]byte(payload), &errorChunk) == nil && errorChunk.Error.Message != "" {
+			return core.LLMResponse{}, p.explain(fmt.Errorf("llm error: %s", errorChunk.Error.Message))
+		}
+		var chunk llamaCppCompletionResponse
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			continue
+		}
+		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
+			if visible, _, _ := strings.Cut(content.String(), llamaCppToolCallOpen); onText != nil && strings.TrimSpace(visible) != "" {
+				onText(visible)
+			}
+		}
+		if chunk.Stop {
+			final = chunk
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return core.LLMResponse{}, fmt.Errorf("read llm stream: %w", err)
+	}
+
+	final.Content = content.String()
+	return p.result(final)
+}
+
+// send renders the prompt and starts the completion. A non-2xx status is
+// returned as an error, so the caller only reads successful bodies.
+func (p *LlamaCppProvider) send(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+	stream bool,
+) (*http.Response, context.CancelFunc, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
+
+	template := llamaCppTemplateRequest{Messages: translateOpenAIMessages(messages)}
+	if len(tools) > 0 {
+		template.Tools = translateOpenAITools(tools)
+	}
+	resp, err := p.post(reqCtx, "/apply-template", template, false)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	var rendered struct {
+		Prompt string `json:"prompt"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&rendered)
+	resp.Body.Close()
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("parse llama.cpp template response: %w", err)
+	}
+
+	completion := llamaCppCompletionRequest{
+		Prompt:      rendered.Prompt,
+		NPredict:    -1,
+		Stream:      stream,
+		CachePrompt: true,
+	}
+	if p.maxTokens > 0 {
+		completion.NPredict = p.maxTokens
+	}
+	if p.temperature > 0 {
+		completion.Temperature = &p.temperature
+	}
+	resp, err = p.post(reqCtx, "/completion", completion, stream)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	return resp, cancel, nil
+}
+
+func (p *LlamaCppProvider) post(ctx context.Context, path string, body any, stream bool) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal llm request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.serverURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("build llm request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("send llm request: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	var decoded llamaCppErrorResponse
+	if json.Unmarshal(raw, &decoded) == nil && decoded.Error.Message != "" {
+		return nil, p.explain(newStatusError(resp, "llm request failed: "+decoded.Error.Message))
+	}
+	if resp.StatusCode == http.StatusNotFound && path == "/apply-template" {
+		return nil, fmt.Errorf("llama.cpp server at %s has no /apply-template endpoint; update llama-server", p.serverURL)
+	}
+	return nil, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
+}
+
+// result turns a finished completion into a response. A truncated prompt is
+// an error: the server dropped part of the conversation to fit its context.
+func (p *LlamaCppProvider) result(decoded llamaCppCompletionResponse) (core.LLMResponse, error) {
+	if decoded.Truncated {
+		return core.LLMResponse{}, fmt.Errorf("llama.cpp truncated the prompt (%d tokens) to fit its context; lower llm.context_tokens or raise llama-server --ctx-size", decoded.TokensEvaluated)
+	}
+
+	content, calls := parseLlamaCppToolCalls(decoded.Content)
+	model := p.model
+	if model == "" {
+		model = decoded.Model
+	}
+	return core.LLMResponse{
+		Content:          strings.TrimSpace(content),
+		ToolCalls:        calls,
+		Model:            model,
+		PromptTokens:     decoded.TokensEvaluated,
+		CompletionTokens: decoded.TokensPredicted,
+	}, nil
+}
+
+// explain replaces the server's terse "Loading model" error.
+func (p *LlamaCppProvider) explain(err error) error {
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "loading model") {
+		return &StatusError{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    fmt.Sprintf("llama.cpp server at %s is still loading its model", p.serverURL),
+		}
+	}
+	return err
+}
+
+// parseLlamaCppToolCalls removes <tool_call>{"name": ..., "arguments": ...}
+// blocks from the generated text and returns them as tool calls. A block that
+// is not valid JSON is left in the text.
+func parseLlamaCppToolCalls(text string) (string, []core.ToolCall) {
+	var calls []core.ToolCall
+	var kept strings.Builder
+	prefix := toolCallIDPrefix()
+	rest := text
+	for {
+		before, after, found := strings.Cut(rest, llamaCppToolCallOpen)
+		kept.WriteString(before)
+		if !found {
+			break
+		}
+		body, remainder, closed := strings.Cut(after, llamaCppToolCallClose)
+
+		var decoded struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(body)), &decoded); err != nil || decoded.Name == "" {
+			kept.WriteString(llamaCppToolCallOpen + after)
+			break
+		}
+		arguments := parseToolArguments(string(decoded.Arguments))
+		// Some templates encode the arguments as a JSON string.
+		var encoded string
+		if json.Unmarshal(decoded.Arguments, &encoded) == nil {
+			arguments = parseToolArguments(encoded)
+		}
+		calls = append(calls, core.ToolCall{
+			ID:        prefix + strconv.Itoa(len(calls)+1),
+			Name:      decoded.Name,
+			Arguments: arguments,
+		})
+		if !closed {
+			break
+		}
+		rest = remainder
+	}
+	return kept.String(), calls
+}
@@ -0,0 +1,131 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"clawkangsar/internal/config"
+	"clawkangsar/internal/core"
+)
+
+func newTestLlamaCpp(t *testing.T, completion http.HandlerFunc) (*LlamaCppProvider, *llamaCppTemplateRequest) {
+	t.Helper()
+	var template llamaCppTemplateRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /apply-template", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&template); err != nil {
+			t.Errorf("decode template request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"prompt":"<rendered>"}`))
+	})
+	mux.HandleFunc("POST /completion", completion)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	provider, err := NewLlamaCppProvider(config.LLMConfig{
+		Enabled:   true,
+		Provider:  "llamacpp",
+		BaseURL:   server.URL,
+		MaxTokens: 64,
+	})
+	if err != nil {
+		t.Fatalf("NewLlamaCppProvider: %v", err)
+	}
+	return provider, &template
+}
+
+func TestLlamaCppCompletionWithToolCall(t *testing.T) {
+	var got llamaCppCompletionRequest
+	provider, template := newTestLlamaCpp(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode completion request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"content":"Checking.\n<tool_call>\n{\"name\": \"web_fetch\", \"arguments\": {\"url\": \"https://example.com\"}}\n</tool_call>","stop":true,"model":"qwen","tokens_evaluated":42,"tokens_predicted":9}`))
+	})
+
+	response, err := provider.Complete(context.Background(),
+		[]core.LLMMessage{{Role: "system", Content: "Be brief."}, {Role: "user", Content: "fetch it"}},
+		[]core.ToolDefinition{{Name: "web_fetch", Description: "Fetch a URL."}},
+	)
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	if len(template.Messages) != 2 || len(template.Tools) != 1 || template.Tools[0].Function.Name != "web_fetch" {
+		t.Errorf("template request = %+v", template)
+	}
+	if got.Prompt != "<rendered>" || got.NPredict != 64 || !got.CachePrompt || got.Stream {
+		t.Errorf("completion request = %+v", got)
+	}
+
+	if response.Content != "Checking." {
+		t.Errorf("content = %q", response.Content)
+	}
+	if response.Model != "qwen" || response.PromptTokens != 42 || response.CompletionTokens != 9 {
+		t.Errorf("response = %+v", response)
+	}
+	if len(response.ToolCalls) != 1 {
+		t.Fatalf("got %d tool calls, want 1", len(response.ToolCalls))
+	}
+	if call := response.ToolCalls[0]; call.Name != "web_fetch" || call.Arguments["url"] != "https://example.com" || call.ID == "" {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestLlamaCppTruncatedPrompt(t *testing.T) {
+	provider, _ := newTestLlamaCpp(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"content":"partial","stop":true,"truncated":true,"tokens_evaluated":4096}`))
+	})
+
+	_, err := provider.Complete(context.Background(), []core.LLMMessage{{Role: "user", Content: "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Fatalf("error = %v, want truncated prompt error", err)
+	}
+}
+
+func TestLlamaCppLoadingModel(t *testing.T) {
+	provider, _ := newTestLlamaCpp(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":{"code":503,"message":"Loading model","type":"unavailable_error"}}`))
+	})
+
+	_, err := provider.Complete(context.Background(), []core.LLMMessage{{Role: "user", Content: "hi"}}, nil)
+	statusErr, ok := err.(*StatusError)
+	if !ok || statusErr.StatusCode != http.StatusServiceUnavailable || !strings.Contains(statusErr.Message, "still loading") {
+		t.Fatalf("error = %v, want loading StatusError", err)
+	}
+}
+
+func TestLlamaCppStream(t *testing.T) {
+	provider, _ := newTestLlamaCpp(t, func(w http.ResponseWriter, _ *http.Request) {
+		for _, piece := range []string{"Hel", "lo", "<tool_call>{\"name\":\"system_metrics\",\"arguments\":{}}", "</tool_call>"} {
+			encoded, _ := json.Marshal(piece)
+			fmt.Fprintf(w, "data: {\"content\":%s,\"stop\":false}\n\n", encoded)
+		}
+		fmt.Fprint(w, "data: {\"content\":\"\",\"stop\":true,\"tokens_evaluated\":5,\"tokens_predicted\":4}\n\n")
+	})
+
+	var partials []string
+	response, err := provider.CompleteStream(context.Background(), []core.LLMMessage{{Role: "user", Content: "hi"}}, nil, func(text string) {
+		partials = append(partials, text)
+	})
+	if err != nil {
+		t.Fatalf("CompleteStream: %v", err)
+	}
+	for _, partial := range partials {
+		if strings.Contains(partial, "tool_call") {
+			t.Errorf("partial text leaked a tool call: %q", partial)
+		}
+	}
+	if response.Content != "Hello" || response.PromptTokens != 5 || response.CompletionTokens != 4 {
+		t.Errorf("response = %+v", response)
+	}
+	if len(response.ToolCalls) != 1 || response.ToolCalls[0].Name != "system_metrics" {
+		t.Errorf("tool calls = %+v", response.ToolCalls)
+	}
+}
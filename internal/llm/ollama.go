@@ -0,0 +1,269 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"clawkangsar/internal/config"
+	"clawkangsar/internal/core"
+)
+
+const ollamaBaseURL = "http://127.0.0.1:11434"
+
+// OllamaProvider uses Ollama's native /api/chat endpoint, which supports
+// tools and keep_alive without the OpenAI compatibility layer.
+type OllamaProvider struct {
+	baseURL     string
+	model       string
+	temperature float64
+	maxTokens   int
+	numCtx      int
+	keepAlive   string
+	timeout     time.Duration
+	client      *http.Client
+}
+
+type ollamaChatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []ollamaMessage `json:"messages"`
+	Tools     []ollamaTool    `json:"tools,omitempty"`
+	Stream    bool            `json:"stream"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaTool struct {
+	Type     string                   `json:"type"`
+	Function openAICompatToolFunction `json:"function"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error,omitempty"`
+}
+
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name  string `json:"name"`
+		Model string `json:"model"`
+	} `json:"models"`
+}
+
+func NewOllamaProvider(cfg config.LLMConfig) (*OllamaProvider, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if strings.TrimSpace(cfg.Model) == "" {
+		return nil, errors.New("llm.model is required when llm.enabled=true")
+	}
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+
+	// Ollama silently truncates prompts to its own default context size, so
+	// size the context to the configured budget plus room for the reply.
+	numCtx := 0
+	if cfg.ContextTokens > 0 {
+		numCtx = cfg.ContextTokens + cfg.MaxTokens
+	}
+
+	return &OllamaProvider{
+		baseURL:     strings.TrimSuffix(providerBaseURL(cfg.BaseURL, ollamaBaseURL), "/v1"),
+		model:       strings.TrimSpace(cfg.Model),
+		temperature: cfg.Temperature,
+		maxTokens:   cfg.MaxTokens,
+		numCtx:      numCtx,
+		keepAlive:   strings.TrimSpace(cfg.KeepAlive),
+		timeout:     timeout,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}, nil
+}
+
+func (p *OllamaProvider) EstimateTokens(msg core.LLMMessage) int {
+	return core.EstimateTokens(msg, 4)
+}
+
+// CheckModel confirms that the model has been pulled into the Ollama server.
+func (p *OllamaProvider) CheckModel(ctx context.Context) error {
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, p.baseURL+"/api/tags", nil)
+	if err != nil {
+		return fmt.Errorf("build ollama tags request: %w", err)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ollama is not reachable at %s: %w", p.baseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama tags request failed with status %d", resp.StatusCode)
+	}
+
+	var decoded ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return fmt.Errorf("parse ollama tags: %w", err)
+	}
+
+	want := ollamaModelKey(p.model)
+	for _, model := range decoded.Models {
+		if ollamaModelKey(model.Name) == want || ollamaModelKey(model.Model) == want {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %q is not available in ollama; run `ollama pull %s`", p.model, p.model)
+}
+
+func (p *OllamaProvider) Complete(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+) (core.LLMResponse, error) {
+	options := map[string]any{
+		"temperature": p.temperature,
+	}
+	if p.maxTokens > 0 {
+		options["num_predict"] = p.maxTokens
+	}
+	if p.numCtx > 0 {
+		options["num_ctx"] = p.numCtx
+	}
+
+	reqBody := ollamaChatRequest{
+		Model:     p.model,
+		Messages:  translateOllamaMessages(messages),
+		Stream:    false,
+		KeepAlive: p.keepAlive,
+		Options:   options,
+	}
+	for _, tool := range translateOpenAITools(tools) {
+		reqBody.Tools = append(reqBody.Tools, ollamaTool{Type: tool.Type, Function: tool.Function})
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("marshal llm request: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, p.baseURL+"/api/chat", bytes.NewReader(payload))
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("build llm request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("send llm request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return core.LLMResponse{}, fmt.Errorf("read llm response: %w", err)
+	}
+
+	var decoded ollamaChatResponse
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
+		}
+		return core.LLMResponse{}, fmt.Errorf("parse llm response: %w", err)
+	}
+	if decoded.Error != "" {
+		if resp.StatusCode == http.StatusNotFound && strings.Contains(decoded.Error, "not found") {
+			return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("model %q is not loaded in ollama; run `ollama pull %s`", p.model, p.model))
+		}
+		return core.LLMResponse{}, newStatusError(resp, "llm request failed: "+decoded.Error)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
+	}
+
+	// Ollama does not assign tool call IDs; number them under a prefix that
+	// is unique to this response so tool results can be matched back.
+	result := core.LLMResponse{
+		Content:          strings.TrimSpace(decoded.Message.Content),
+		Model:            p.model,
+		PromptTokens:     decoded.PromptEvalCount,
+		CompletionTokens: decoded.EvalCount,
+	}
+	prefix := toolCallIDPrefix()
+	for i, call := range decoded.Message.ToolCalls {
+		args := call.Function.Arguments
+		if args == nil {
+			args = make(map[string]any)
+		}
+		result.ToolCalls = append(result.ToolCalls, core.ToolCall{
+			ID:        prefix + strconv.Itoa(i),
+			Name:      call.Function.Name,
+			Arguments: args,
+		})
+	}
+	return result, nil
+}
+
+func translateOllamaMessages(messages []core.LLMMessage) []ollamaMessage {
+	toolNames := make(map[string]string)
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, item := range messages {
+		msg := ollamaMessage{
+			Role:    item.Role,
+			Content: item.Content,
+		}
+		for _, call := range item.ToolCalls {
+			toolNames[call.ID] = call.Name
+			var toolCall ollamaToolCall
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = call.Arguments
+			msg.ToolCalls = append(msg.ToolCalls, toolCall)
+		}
+		if item.Role == "tool" {
+			msg.ToolName = toolNames[item.ToolCallID]
+		}
+		out = append(out, msg)
+	}
+	return out
+}
+
+// ollamaModelKey treats "llama3.2" and "llama3.2:latest" as the same model.
+func ollamaModelKey(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.Contains(name, ":") {
+		name += ":latest"
+	}
+	return name
+}
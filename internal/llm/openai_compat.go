@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -33,6 +34,17 @@ type openAICompatRequest struct {
 	ToolChoice  string                    `json:"tool_choice,omitempty"`
 	Temperature float64                   `json:"temperature,omitempty"`
 	MaxTokens   int                       `json:"max_tokens,omitempty"`
+	Stream      bool                      `json:"stream,omitempty"`
+	StreamOpts  *openAICompatStreamOpts   `json:"stream_options,omitempty"`
+}
+
+type openAICompatStreamOpts struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+type openAICompatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
 type openAICompatMessage struct {
@@ -75,6 +87,26 @@ type openAICompatResponse struct {
 			ToolCalls []openAICompatToolCall `json:"tool_calls"`
 		} `json:"message"`
 	} `json:"choices"`
+	Model string             `json:"model"`
+	Usage *openAICompatUsage `json:"usage,omitempty"`
+}
+
+type openAICompatStreamChunk struct {
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int                         `json:"index"`
+				ID       string                      `json:"id"`
+				Function openAICompatToolCallPayload `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Model string             `json:"model"`
+	Usage *openAICompatUsage `json:"usage,omitempty"`
 }
 
 func NewOpenAICompatProvider(cfg config.LLMConfig) (*OpenAICompatProvider, error) {
@@ -115,41 +147,21 @@ func NewOpenAICompatProvider(cfg config.LLMConfig) (*OpenAICompatProvider, error
 	}, nil
 }
 
+// EstimateTokens approximates prompt size at roughly four characters per token.
+func (p *OpenAICompatProvider) EstimateTokens(msg core.LLMMessage) int {
+	return core.EstimateTokens(msg, 4)
+}
+
 func (p *OpenAICompatProvider) Complete(
 	ctx context.Context,
 	messages []core.LLMMessage,
 	tools []core.ToolDefinition,
 ) (core.LLMResponse, error) {
-	reqBody := openAICompatRequest{
-		Model:       p.model,
-		Messages:    translateOpenAIMessages(messages),
-		Temperature: p.temperature,
-		MaxTokens:   p.maxTokens,
-	}
-	if len(tools) > 0 {
-		reqBody.Tools = translateOpenAITools(tools)
-		reqBody.ToolChoice = "auto"
-	}
-
-	payload, err := json.Marshal(reqBody)
+	resp, cancel, err := p.send(ctx, messages, tools, false)
 	if err != nil {
-		return core.LLMResponse{}, fmt.Errorf("marshal llm request: %w", err)
+		return core.LLMResponse{}, err
 	}
-
-	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
 	defer cancel()
-
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(payload))
-	if err != nil {
-		return core.LLMResponse{}, fmt.Errorf("build llm request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
-
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return core.LLMResponse{}, fmt.Errorf("send llm request: %w", err)
-	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
@@ -159,14 +171,17 @@ func (p *OpenAICompatProvider) Complete(
 
 	var decoded openAICompatResponse
 	if err := json.Unmarshal(body, &decoded); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
+		}
 		return core.LLMResponse{}, fmt.Errorf("parse llm response: %w", err)
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		if decoded.Error != nil && decoded.Error.Message != "" {
-			return core.LLMResponse{}, fmt.Errorf("llm request failed: %s", decoded.Error.Message)
+			return core.LLMResponse{}, newStatusError(resp, "llm request failed: "+decoded.Error.Message)
 		}
-		return core.LLMResponse{}, fmt.Errorf("llm request failed with status %d", resp.StatusCode)
+		return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
 	}
 	if decoded.Error != nil && decoded.Error.Message != "" {
 		return core.LLMResponse{}, fmt.Errorf("llm error: %s", decoded.Error.Message)
@@ -178,24 +193,183 @@ func (p *OpenAICompatProvider) Complete(
 	choice := decoded.Choices[0].Message
 	result := core.LLMResponse{
 		Content: strings.TrimSpace(extractContent(choice.Content)),
+		Model:   p.responseModel(decoded.Model),
+	}
+	if decoded.Usage != nil {
+		result.PromptTokens = decoded.Usage.PromptTokens
+		result.CompletionTokens = decoded.Usage.CompletionTokens
 	}
 	for _, toolCall := range choice.ToolCalls {
-		args := make(map[string]any)
-		if strings.TrimSpace(toolCall.Function.Arguments) != "" {
-			if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-				args["raw"] = toolCall.Function.Arguments
+		result.ToolCalls = append(result.ToolCalls, core.ToolCall{
+			ID:        toolCall.ID,
+			Name:      toolCall.Function.Name,
+			Arguments: parseToolArguments(toolCall.Function.Arguments),
+		})
+	}
+
+	return result, nil
+}
+
+// CompleteStream requests a server-sent event stream and reports the text as
+// it arrives. Tool call fragments are assembled by their index.
+func (p *OpenAICompatProvider) CompleteStream(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+	onText core.PartialFunc,
+) (core.LLMResponse, error) {
+	resp, cancel, err := p.send(ctx, messages, tools, true)
+	if err != nil {
+		return core.LLMResponse{}, err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		var decoded openAICompatResponse
+		if json.Unmarshal(body, &decoded) == nil && decoded.Error != nil && decoded.Error.Message != "" {
+			return core.LLMResponse{}, newStatusError(resp, "llm request failed: "+decoded.Error.Message)
+		}
+		return core.LLMResponse{}, newStatusError(resp, fmt.Sprintf("llm request failed with status %d", resp.StatusCode))
+	}
+
+	var content strings.Builder
+	var usage openAICompatUsage
+	model := ""
+	calls := make([]openAICompatToolCall, 0, 2)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 16*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if payload == "[DONE]" {
+			break
+		}
+
+		var chunk openAICompatStreamChunk
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			continue
+		}
+		if chunk.Error != nil && chunk.Error.Message != "" {
+			return core.LLMResponse{}, fmt.Errorf("llm error: %s", chunk.Error.Message)
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+		// With include_usage the last chunk carries the totals and no choices.
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			if onText != nil {
+				onText(content.String())
+			}
+		}
+		for _, fragment := range delta.ToolCalls {
+			if fragment.Index < 0 || fragment.Index > 63 {
+				continue
+			}
+			for len(calls) <= fragment.Index {
+				calls = append(calls, openAICompatToolCall{Type: "function"})
 			}
+			call := &calls[fragment.Index]
+			if fragment.ID != "" {
+				call.ID = fragment.ID
+			}
+			call.Function.Name += fragment.Function.Name
+			call.Function.Arguments += fragment.Function.Arguments
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return core.LLMResponse{}, fmt.Errorf("read llm stream: %w", err)
+	}
+
+	result := core.LLMResponse{
+		Content:          strings.TrimSpace(content.String()),
+		Model:            p.responseModel(model),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+	}
+	for _, toolCall := range calls {
+		if toolCall.Function.Name == "" {
+			continue
 		}
 		result.ToolCalls = append(result.ToolCalls, core.ToolCall{
 			ID:        toolCall.ID,
 			Name:      toolCall.Function.Name,
-			Arguments: args,
+			Arguments: parseToolArguments(toolCall.Function.Arguments),
 		})
 	}
-
 	return result, nil
 }
 
+func (p *OpenAICompatProvider) send(
+	ctx context.Context,
+	messages []core.LLMMessage,
+	tools []core.ToolDefinition,
+	stream bool,
+) (*http.Response, context.CancelFunc, error) {
+	reqBody := openAICompatRequest{
+		Model:       p.model,
+		Messages:    translateOpenAIMessages(messages),
+		Temperature: p.temperature,
+		MaxTokens:   p.maxTokens,
+		Stream:      stream,
+	}
+	if stream {
+		reqBody.StreamOpts = &openAICompatStreamOpts{IncludeUsage: true}
+	}
+	if len(tools) > 0 {
+		reqBody.Tools = translateOpenAITools(tools)
+		reqBody.ToolChoice = "auto"
+	}
+
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal llm request: %w", err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, p.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("build llm request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("send llm request: %w", err)
+	}
+	return resp, cancel, nil
+}
+
+// responseModel prefers the configured name so usage is priced consistently;
+// llama.cpp may run without one and then reports the loaded model.
+func (p *OpenAICompatProvider) responseModel(reported string) string {
+	if p.model != "" {
+		return p.model
+	}
+	return reported
+}
+
 func translateOpenAIMessages(messages []core.LLMMessage) []openAICompatMessage {
 	out := make([]openAICompatMessage, 0, len(messages))
 	for _, item := range messages {
@@ -240,6 +414,16 @@ func translateOpenAITools(tools []core.ToolDefinition) []openAICompatTool {
 	return out
 }
 
+func parseToolArguments(raw string) map[string]any {
+	args := make(map[string]any)
+	if strings.TrimSpace(raw) != "" {
+		if err := json.Unmarshal([]byte(raw), &args); err != nil {
+			args["raw"] = raw
+		}
+	}
+	return args
+}
+
 func extractContent(raw any) string {
 	switch v := raw.(type) {
 	case string:
@@ -0,0 +1,32 @@
+package llm
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ModelChecker is implemented by providers that can verify at startup that
+// the configured model is actually served.
+type ModelChecker interface {
+	CheckModel(ctx context.Context) error
+}
+
+// toolCallIDPrefix starts the tool call IDs of one response from a server
+// that assigns none. IDs must stay unique across the tool rounds of a reply,
+// since a fallback provider may receive the whole exchange and reject
+// duplicates.
+func toolCallIDPrefix() string {
+	return "call_" + strconv.FormatInt(time.Now().UnixNano(), 36) + "_"
+}
+
+// providerBaseURL returns the configured base URL unless it is empty or still
+// the OpenAI default from config.Default, in which case fallback is used.
+func providerBaseURL(configured string, fallback string) string {
+	configured = strings.TrimSpace(configured)
+	if configured == "" || strings.Contains(configured, "api.openai.com") {
+		return fallback
+	}
+	return strings.TrimRight(configured, "/")
+}
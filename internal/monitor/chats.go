@@ -0,0 +1,66 @@
+// Package monitor watches the host and sends alerts to chats through the
+// notification router.
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"clawkangsar/internal/core"
+)
+
+// maxAlertLogChars keeps an alert with attached log lines inside one chat
+// message; gateways clip the end of longer messages, which would cut off the
+// newest lines.
+const maxAlertLogChars = 3000
+
+// Chat is an alert destination.
+type Chat struct {
+	Channel string
+	ChatID  string
+}
+
+func (c Chat) String() string {
+	return c.Channel + ":" + c.ChatID
+}
+
+// ParseChats reads "<channel>:<chat id>" entries such as "telegram:12345" or
+// "whatsapp:120363000000000000@g.us".
+func ParseChats(entries []string) ([]Chat, error) {
+	chats := make([]Chat, 0, len(entries))
+	for _, entry := range entries {
+		channel, chatID, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		chatID = strings.TrimSpace(chatID)
+		if !ok || channel == "" || chatID == "" {
+			return nil, fmt.Errorf("invalid chat %q; use <channel>:<chat id>", entry)
+		}
+		chats = append(chats, Chat{Channel: channel, ChatID: chatID})
+	}
+	return chats, nil
+}
+
+func deliverToChats(ctx context.Context, deliver core.DeliverFunc, chats []Chat, text string, logger *slog.Logger) {
+	if deliver == nil {
+		return
+	}
+	for _, chat := range chats {
+		if err := deliver(ctx, chat.Channel, chat.ChatID, text); err != nil {
+			logger.Warn("alert delivery failed", "chat", chat.String(), "error", err)
+		}
+	}
+}
+
+// tailText keeps the end of text, cut at a line boundary.
+func tailText(text string, maxChars int) string {
+	if len(text) <= maxChars {
+		return text
+	}
+	text = text[len(text)-maxChars:]
+	if _, rest, ok := strings.Cut(text, "\n"); ok {
+		text = rest
+	}
+	return "...\n" + text
+}
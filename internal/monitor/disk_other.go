@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package monitor
+
+import "errors"
+
+func diskUsage(string) (DiskUsage, error) {
+	return DiskUsage{}, errors.New("disk usage is not supported on this platform")
+}
@@ -0,0 +1,27 @@
+//go:build linux || darwin
+
+package monitor
+
+import "syscall"
+
+func diskUsage(mount string) (DiskUsage, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(mount, &stat); err != nil {
+		return DiskUsage{}, err
+	}
+
+	blockSize := uint64(stat.Bsize)
+	total := uint64(stat.Blocks) * blockSize
+	free := uint64(stat.Bavail) * blockSize
+	// Used space counts blocks reserved for root as used, like df.
+	used := total - uint64(stat.Bfree)*blockSize
+	usage := DiskUsage{
+		Mount:   mount,
+		TotalGB: float64(total) / (1 << 30),
+		FreeGB:  float64(free) / (1 << 30),
+	}
+	if used+free > 0 {
+		usage.UsedPercent = float64(used) * 100 / float64(used+free)
+	}
+	return usage, nil
+}
@@ -0,0 +1,451 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"clawkangsar/internal/core"
+	"clawkangsar/internal/tools"
+)
+
+const (
+	kindUnit = "unit"
+
+	// afterContextWait bounds how long an alert waits for the lines that
+	// follow a match.
+	afterContextWait = 2 * time.Second
+	maxLogLineBytes  = 64 << 10
+	truncatedSuffix  = " [truncated]"
+	followRetryMin   = 5 * time.Second
+	followRetryMax   = 5 * time.Minute
+)
+
+// LogRule alerts on lines matching Pattern. Sources limits it to some units
+// or containers and Chats overrides the watcher's chats; both may be empty.
+type LogRule struct {
+	Name     string
+	Pattern  string
+	Sources  []string
+	Chats    []string
+	Cooldown time.Duration
+}
+
+type LogWatcherOptions struct {
+	Control    *tools.ServerControl
+	Units      []string
+	Containers []string
+	// Chats receive the alerts, each as "<channel>:<chat id>".
+	Chats        []string
+	Rules        []LogRule
+	ContextLines int
+	Deliver      core.DeliverFunc
+	Logger       *slog.Logger
+}
+
+// LogSourceStatus describes one followed unit or container.
+type LogSourceStatus struct {
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Following bool      `json:"following"`
+	Matches   int       `json:"matches"`
+	LastMatch time.Time `json:"last_match,omitzero"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// LogWatcher follows journal units and container logs and alerts chats when
+// a line matches a rule. Each rule alerts at most once per cooldown; matches
+// in between are counted and reported with the next alert.
+type LogWatcher struct {
+	mu           sync.Mutex
+	control      *tools.ServerControl
+	chats        []Chat
+	rules        []*logRule
+	contextLines int
+	deliver      core.DeliverFunc
+	logger       *slog.Logger
+	sources      []*LogSourceStatus
+}
+
+type logRule struct {
+	name       string
+	pattern    *regexp.Regexp
+	sources    map[string]struct{}
+	chats      []Chat
+	cooldown   time.Duration
+	lastAlert  time.Time
+	suppressed int
+}
+
+type pendingMatch struct {
+	rule       *logRule
+	lines      []string
+	after      int
+	suppressed int
+	deadline   time.Time
+}
+
+func NewLogWatcher(opts LogWatcherOptions) (*LogWatcher, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if opts.Control == nil {
+		return nil, fmt.Errorf("log watcher needs server control")
+	}
+	chats, err := ParseChats(opts.Chats)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &LogWatcher{
+		control:      opts.Control,
+		chats:        chats,
+		contextLines: max(opts.ContextLines, 0),
+		deliver:      opts.Deliver,
+		logger:       logger,
+	}
+
+	seen := make(map[string]struct{}, len(opts.Rules))
+	for i, rule := range opts.Rules {
+		name := strings.TrimSpace(rule.Name)
+		if name == "" {
+			name = fmt.Sprintf("rule%d", i+1)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("duplicate log rule %q", name)
+		}
+		seen[name] = struct{}{}
+
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil || strings.TrimSpace(rule.Pattern) == "" {
+			return nil, fmt.Errorf("log rule %q: invalid pattern %q", name, rule.Pattern)
+		}
+		ruleChats, err := ParseChats(rule.Chats)
+		if err != nil {
+			return nil, fmt.Errorf("log rule %q: %w", name, err)
+		}
+		if len(ruleChats) == 0 {
+			ruleChats = chats
+		}
+		sources := make(map[string]struct{}, len(rule.Sources))
+		for _, source := range rule.Sources {
+			if source = strings.ToLower(strings.TrimSpace(source)); source != "" {
+				sources[source] = struct{}{}
+			}
+		}
+		watcher.rules = append(watcher.rules, &logRule{
+			name:     name,
+			pattern:  pattern,
+			sources:  sources,
+			chats:    ruleChats,
+			cooldown: max(rule.Cooldown, 0),
+		})
+	}
+
+	for _, unit := range uniqueNames(opts.Units) {
+		watcher.sources = append(watcher.sources, &LogSourceStatus{Kind: kindUnit, Name: unit})
+	}
+	for _, container := range uniqueNames(opts.Containers) {
+		watcher.sources = append(watcher.sources, &LogSourceStatus{Kind: kindContainer, Name: container})
+	}
+	return watcher, nil
+}
+
+// Empty reports whether there is nothing to follow or nothing to match.
+func (w *LogWatcher) Empty() bool {
+	return len(w.sources) == 0 || len(w.rules) == 0
+}
+
+// Run follows every source until ctx is done, restarting followers that
+// exit, such as docker logs when its container restarts.
+func (w *LogWatcher) Run(ctx context.Context) {
+	if w.Empty() {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, source := range w.sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.followLoop(ctx, source)
+		}()
+	}
+	wg.Wait()
+}
+
+// Snapshot returns the followed sources for /status.
+func (w *LogWatcher) Snapshot() []LogSourceStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out := make([]LogSourceStatus, 0, len(w.sources))
+	for _, source := range w.sources {
+		out = append(out, *source)
+	}
+	return out
+}
+
+func (w *LogWatcher) followLoop(ctx context.Context, source *LogSourceStatus) {
+	retry := followRetryMin
+	for {
+		started := time.Now()
+		err := w.follow(ctx, source)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > followRetryMax {
+			retry = followRetryMin
+		}
+		if err == nil {
+			err = errors.New("log stream ended")
+		}
+		w.mu.Lock()
+		source.Following = false
+		source.LastError = err.Error()
+		w.mu.Unlock()
+		w.logger.Warn("log follow stopped; retrying", "kind", source.Kind, "name", source.Name, "retry_in", retry, "error", err)
+
+		timer := time.NewTimer(retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		retry = min(retry*2, followRetryMax)
+	}
+}
+
+func (w *LogWatcher) follow(ctx context.Context, source *LogSourceStatus) error {
+	var (
+		stream *tools.LogStream
+		err    error
+	)
+	if source.Kind == kindContainer {
+		stream, err = w.control.DockerFollow(ctx, source.Name)
+	} else {
+		stream, err = w.control.JournalFollow(ctx, source.Name)
+	}
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	w.mu.Lock()
+	source.Following = true
+	source.LastError = ""
+	w.mu.Unlock()
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(stream.Output)
+		scanner.Buffer(make([]byte, 0, 4096), maxLogLineBytes)
+		scanner.Split(truncatedLines(maxLogLineBytes))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if source.Kind == kindUnit {
+				line = journalMessage(line)
+			}
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
+	rules := w.rulesFor(source)
+	history := make([]string, 0, w.contextLines)
+	var pending []*pendingMatch
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			pending = w.flush(ctx, source, pending, now)
+		case line, ok := <-lines:
+			if !ok {
+				for _, match := range pending {
+					w.send(ctx, source, match)
+				}
+				select {
+				case err := <-readErr:
+					if err != nil && !errors.Is(err, io.EOF) {
+						return err
+					}
+				default:
+				}
+				return nil
+			}
+
+			line = strings.TrimRight(line, "\r")
+			for _, match := range pending {
+				if match.after < w.contextLines {
+					match.lines = append(match.lines, "  "+line)
+					match.after++
+				}
+			}
+			for _, rule := range rules {
+				if !rule.pattern.MatchString(line) {
+					continue
+				}
+				suppressed, ok := w.allow(rule, source, time.Now())
+				if !ok {
+					continue
+				}
+				match := &pendingMatch{
+					rule:       rule,
+					suppressed: suppressed,
+					deadline:   time.Now().Add(afterContextWait),
+				}
+				for _, before := range history {
+					match.lines = append(match.lines, "  "+before)
+				}
+				match.lines = append(match.lines, "> "+line)
+				pending = append(pending, match)
+			}
+			pending = w.flush(ctx, source, pending, time.Now())
+
+			if w.contextLines > 0 {
+				if len(history) == w.contextLines {
+					history = append(history[:0], history[1:]...)
+				}
+				history = append(history, line)
+			}
+		}
+	}
+}
+
+// flush sends matches that have their trailing context or waited long
+// enough, and returns the rest.
+func (w *LogWatcher) flush(ctx context.Context, source *LogSourceStatus, pending []*pendingMatch, now time.Time) []*pendingMatch {
+	kept := pending[:0]
+	for _, match := range pending {
+		if match.after >= w.contextLines || !now.Before(match.deadline) {
+			w.send(ctx, source, match)
+			continue
+		}
+		kept = append(kept, match)
+	}
+	return kept
+}
+
+// allow applies the rule cooldown and returns how many matches it held back
+// since the last alert.
+func (w *LogWatcher) allow(rule *logRule, source *LogSourceStatus, now time.Time) (int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	source.Matches++
+	source.LastMatch = now
+	if !rule.lastAlert.IsZero() && now.Sub(rule.lastAlert) < rule.cooldown {
+		rule.suppressed++
+		return 0, false
+	}
+	suppressed := rule.suppressed
+	rule.lastAlert = now
+	rule.suppressed = 0
+	return suppressed, true
+}
+
+func (w *LogWatcher) send(ctx context.Context, source *LogSourceStatus, match *pendingMatch) {
+	w.logger.Warn("log rule matched", "rule", match.rule.name, "kind", source.Kind, "name", source.Name)
+
+	header := fmt.Sprintf("Log match %q in %s %s", match.rule.name, source.Kind, source.Name)
+	if match.suppressed > 0 {
+		header += fmt.Sprintf(" (%d more since the last alert)", match.suppressed)
+	}
+	text := header + ":\n" + tailText(strings.Join(match.lines, "\n"), maxAlertLogChars)
+	deliverToChats(ctx, w.deliver, match.rule.chats, text, w.logger)
+}
+
+func (w *LogWatcher) rulesFor(source *LogSourceStatus) []*logRule {
+	name := strings.ToLower(source.Name)
+	rules := make([]*logRule, 0, len(w.rules))
+	for _, rule := range w.rules {
+		if len(rule.sources) > 0 {
+			_, ok := rule.sources[name]
+			if !ok && source.Kind == kindUnit {
+				_, ok = rule.sources[strings.TrimSuffix(name, ".service")]
+			}
+			if !ok {
+				continue
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// journalMessage extracts MESSAGE from a "journalctl -o json" line. Binary
+// messages are encoded by journalctl as an array of bytes.
+// truncatedLines splits like bufio.ScanLines, but cuts a line longer than
+// limit to its first limit bytes and skips the rest of it, instead of
+// failing the scan with bufio.ErrTooLong.
+func truncatedLines(limit int) bufio.SplitFunc {
+	skipping := false
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			if skipping {
+				skipping = false
+				return i + 1, nil, nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if len(data) >= limit {
+			if skipping {
+				return len(data), nil, nil
+			}
+			skipping = true
+			return len(data), append(data[:limit:limit], truncatedSuffix...), nil
+		}
+		if atEOF && len(data) > 0 {
+			if skipping {
+				return len(data), nil, nil
+			}
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func journalMessage(line string) string {
+	var entry struct {
+		Message json.RawMessage `json:"MESSAGE"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || len(entry.Message) == 0 {
+		return line
+	}
+
+	var text string
+	if err := json.Unmarshal(entry.Message, &text); err == nil {
+		return text
+	}
+	var values []int
+	if err := json.Unmarshal(entry.Message, &values); err == nil {
+		raw := make([]byte, 0, len(values))
+		for _, value := range values {
+			raw = append(raw, byte(value))
+		}
+		return string(raw)
+	}
+	return line
+}
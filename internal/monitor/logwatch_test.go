@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestTruncatedLines(t *testing.T) {
+	const limit = 64
+	long := strings.Repeat("x", 3*limit)
+	input := "first\r\n" + long + "\nafter long\n" + long + "\nlast without newline"
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	scanner.Buffer(make([]byte, 0, 16), limit)
+	scanner.Split(truncatedLines(limit))
+
+	var got []string
+	for scanner.Scan() {
+		got = append(got, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	cut := long[:limit] + truncatedSuffix
+	want := []string{"first\r", cut, "after long", cut, "last without newline"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("lines = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,294 @@
+package monitor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// throttledPath is exposed by the Raspberry Pi firmware driver and holds the
+// same bit field as "vcgencmd get_throttled".
+const throttledPath = "devices/platform/soc/soc:firmware/get_throttled"
+
+// Metrics is one reading of the host. Fields that could not be read are left
+// zero and the reason is listed in Errors.
+type Metrics struct {
+	Time            time.Time     `json:"time"`
+	CPUs            int           `json:"cpus"`
+	Load1           float64       `json:"load1"`
+	Load5           float64       `json:"load5"`
+	Load15          float64       `json:"load15"`
+	Temperatures    []Temperature `json:"temperatures,omitempty"`
+	MemTotalMB      int64         `json:"mem_total_mb"`
+	MemAvailableMB  int64         `json:"mem_available_mb"`
+	MemUsedPercent  float64       `json:"mem_used_percent"`
+	SwapTotalMB     int64         `json:"swap_total_mb"`
+	SwapUsedPercent float64       `json:"swap_used_percent"`
+	Disks           []DiskUsage   `json:"disks,omitempty"`
+	Throttling      *Throttling   `json:"throttling,omitempty"`
+	Errors          []string      `json:"errors,omitempty"`
+}
+
+type Temperature struct {
+	Zone    string  `json:"zone"`
+	Celsius float64 `json:"celsius"`
+}
+
+type DiskUsage struct {
+	Mount       string  `json:"mount"`
+	TotalGB     float64 `json:"total_gb"`
+	FreeGB      float64 `json:"free_gb"`
+	UsedPercent float64 `json:"used_percent"`
+}
+
+// Throttling decodes the Raspberry Pi firmware flags. The Now fields describe
+// the current state; the Occurred fields stick until the next reboot.
+type Throttling struct {
+	Raw                     uint64 `json:"raw"`
+	UnderVoltage            bool   `json:"under_voltage"`
+	FrequencyCapped         bool   `json:"frequency_capped"`
+	Throttled               bool   `json:"throttled"`
+	SoftTempLimit           bool   `json:"soft_temp_limit"`
+	UnderVoltageOccurred    bool   `json:"under_voltage_occurred"`
+	FrequencyCappedOccurred bool   `json:"frequency_capped_occurred"`
+	ThrottledOccurred       bool   `json:"throttled_occurred"`
+	SoftTempLimitOccurred   bool   `json:"soft_temp_limit_occurred"`
+}
+
+// Active reports whether any throttling condition is present right now.
+func (t Throttling) Active() bool {
+	return t.Raw&0xf != 0
+}
+
+// Current lists the conditions present right now.
+func (t Throttling) Current() []string {
+	var out []string
+	if t.UnderVoltage {
+		out = append(out, "under-voltage")
+	}
+	if t.FrequencyCapped {
+		out = append(out, "frequency capped")
+	}
+	if t.Throttled {
+		out = append(out, "throttled")
+	}
+	if t.SoftTempLimit {
+		out = append(out, "soft temperature limit")
+	}
+	return out
+}
+
+// MaxTemperature returns the hottest thermal zone, or false when none was read.
+func (m Metrics) MaxTemperature() (Temperature, bool) {
+	if len(m.Temperatures) == 0 {
+		return Temperature{}, false
+	}
+	hottest := m.Temperatures[0]
+	for _, temp := range m.Temperatures[1:] {
+		if temp.Celsius > hottest.Celsius {
+			hottest = temp
+		}
+	}
+	return hottest, true
+}
+
+// Format renders the reading as short lines for chat and the LLM.
+func (m Metrics) Format() string {
+	lines := []string{
+		fmt.Sprintf("load: %.2f %.2f %.2f (%d CPUs)", m.Load1, m.Load5, m.Load15, m.CPUs),
+	}
+	for _, temp := range m.Temperatures {
+		lines = append(lines, fmt.Sprintf("temperature %s: %.1f C", temp.Zone, temp.Celsius))
+	}
+	if m.MemTotalMB > 0 {
+		lines = append(lines, fmt.Sprintf("memory: %.0f%% used, %d of %d MB available", m.MemUsedPercent, m.MemAvailableMB, m.MemTotalMB))
+	}
+	if m.SwapTotalMB > 0 {
+		lines = append(lines, fmt.Sprintf("swap: %.0f%% used of %d MB", m.SwapUsedPercent, m.SwapTotalMB))
+	}
+	for _, disk := range m.Disks {
+		lines = append(lines, fmt.Sprintf("disk %s: %.0f%% used, %.1f of %.1f GB free", disk.Mount, disk.UsedPercent, disk.FreeGB, disk.TotalGB))
+	}
+	if m.Throttling != nil {
+		current := "none"
+		if m.Throttling.Active() {
+			current = strings.Join(m.Throttling.Current(), ", ")
+		}
+		line := fmt.Sprintf("throttling: %s (0x%x)", current, m.Throttling.Raw)
+		if m.Throttling.UnderVoltageOccurred {
+			line += ", under-voltage since boot"
+		}
+		if m.Throttling.ThrottledOccurred {
+			line += ", throttled since boot"
+		}
+		lines = append(lines, line)
+	}
+	for _, err := range m.Errors {
+		lines = append(lines, "unavailable: "+err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// MetricsCollector reads host metrics from /proc and /sys.
+type MetricsCollector struct {
+	mounts   []string
+	procRoot string
+	sysRoot  string
+}
+
+// NewMetricsCollector reports disk usage for mounts, or "/" when empty.
+func NewMetricsCollector(mounts []string) *MetricsCollector {
+	collector := &MetricsCollector{procRoot: "/proc", sysRoot: "/sys"}
+	for _, mount := range mounts {
+		if mount = strings.TrimSpace(mount); mount != "" {
+			collector.mounts = append(collector.mounts, mount)
+		}
+	}
+	if len(collector.mounts) == 0 {
+		collector.mounts = []string{"/"}
+	}
+	return collector
+}
+
+func (c *MetricsCollector) Collect() Metrics {
+	metrics := Metrics{Time: time.Now(), CPUs: runtime.NumCPU()}
+
+	if err := c.readLoad(&metrics); err != nil {
+		metrics.Errors = append(metrics.Errors, "load: "+err.Error())
+	}
+	if err := c.readMemory(&metrics); err != nil {
+		metrics.Errors = append(metrics.Errors, "memory: "+err.Error())
+	}
+	metrics.Temperatures = c.readTemperatures()
+	for _, mount := range c.mounts {
+		disk, err := diskUsage(mount)
+		if err != nil {
+			metrics.Errors = append(metrics.Errors, "disk "+mount+": "+err.Error())
+			continue
+		}
+		metrics.Disks = append(metrics.Disks, disk)
+	}
+	metrics.Throttling = c.readThrottling()
+	return metrics
+}
+
+func (c *MetricsCollector) readLoad(metrics *Metrics) error {
+	payload, err := os.ReadFile(filepath.Join(c.procRoot, "loadavg"))
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(payload))
+	if len(fields) < 3 {
+		return fmt.Errorf("unexpected loadavg %q", strings.TrimSpace(string(payload)))
+	}
+	values := make([]float64, 3)
+	for i := range values {
+		if values[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return fmt.Errorf("parse loadavg: %w", err)
+		}
+	}
+	metrics.Load1, metrics.Load5, metrics.Load15 = values[0], values[1], values[2]
+	return nil
+}
+
+func (c *MetricsCollector) readMemory(metrics *Metrics) error {
+	file, err := os.Open(filepath.Join(c.procRoot, "meminfo"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	values := make(map[string]int64, 8)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		if kb, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			values[key] = kb
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	total := values["MemTotal"]
+	if total <= 0 {
+		return fmt.Errorf("MemTotal missing")
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	metrics.MemTotalMB = total / 1024
+	metrics.MemAvailableMB = available / 1024
+	metrics.MemUsedPercent = percent(total-available, total)
+	if swap := values["SwapTotal"]; swap > 0 {
+		metrics.SwapTotalMB = swap / 1024
+		metrics.SwapUsedPercent = percent(swap-values["SwapFree"], swap)
+	}
+	return nil
+}
+
+func (c *MetricsCollector) readTemperatures() []Temperature {
+	zones, _ := filepath.Glob(filepath.Join(c.sysRoot, "class/thermal/thermal_zone*"))
+	sort.Strings(zones)
+
+	temps := make([]Temperature, 0, len(zones))
+	for _, zone := range zones {
+		payload, err := os.ReadFile(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.ParseInt(strings.TrimSpace(string(payload)), 10, 64)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(zone)
+		if kind, err := os.ReadFile(filepath.Join(zone, "type")); err == nil && strings.TrimSpace(string(kind)) != "" {
+			name = strings.TrimSpace(string(kind))
+		}
+		temps = append(temps, Temperature{Zone: name, Celsius: float64(milli) / 1000})
+	}
+	return temps
+}
+
+func (c *MetricsCollector) readThrottling() *Throttling {
+	payload, err := os.ReadFile(filepath.Join(c.sysRoot, throttledPath))
+	if err != nil {
+		return nil
+	}
+	raw, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimSpace(string(payload)), "0x"), 16, 64)
+	if err != nil {
+		return nil
+	}
+	return &Throttling{
+		Raw:                     raw,
+		UnderVoltage:            raw&(1<<0) != 0,
+		FrequencyCapped:         raw&(1<<1) != 0,
+		Throttled:               raw&(1<<2) != 0,
+		SoftTempLimit:           raw&(1<<3) != 0,
+		UnderVoltageOccurred:    raw&(1<<16) != 0,
+		FrequencyCappedOccurred: raw&(1<<17) != 0,
+		ThrottledOccurred:       raw&(1<<18) != 0,
+		SoftTempLimitOccurred:   raw&(1<<19) != 0,
+	}
+}
+
+func percent(part int64, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
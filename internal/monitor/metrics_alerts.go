@@ -0,0 +1,191 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"clawkangsar/internal/core"
+)
+
+// Thresholds trigger metric alerts. A zero threshold turns that alert off.
+// An alert clears once the value drops HysteresisPercent below its threshold,
+// so a reading hovering around the limit does not flap.
+type Thresholds struct {
+	LoadPerCPU        float64
+	TemperatureC      float64
+	MemoryPercent     float64
+	DiskPercent       float64
+	HysteresisPercent float64
+	Throttling        bool
+}
+
+type MetricsAlertOptions struct {
+	Collector  *MetricsCollector
+	Thresholds Thresholds
+	// Chats receive the alerts, each as "<channel>:<chat id>".
+	Chats    []string
+	Deliver  core.DeliverFunc
+	Interval time.Duration
+	Logger   *slog.Logger
+}
+
+// MetricsAlerts samples the collector and alerts the configured chats when a
+// threshold is crossed and again when the reading is back to normal.
+type MetricsAlerts struct {
+	collector  *MetricsCollector
+	thresholds Thresholds
+	chats      []Chat
+	deliver    core.DeliverFunc
+	interval   time.Duration
+	logger     *slog.Logger
+	active     map[string]bool
+}
+
+// metricCheck is one value compared against a threshold.
+type metricCheck struct {
+	key       string
+	label     string
+	value     float64
+	threshold float64
+	unit      string
+}
+
+func NewMetricsAlerts(opts MetricsAlertOptions) (*MetricsAlerts, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if opts.Collector == nil {
+		return nil, fmt.Errorf("metrics alerts need a collector")
+	}
+	chats, err := ParseChats(opts.Chats)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := &MetricsAlerts{
+		collector:  opts.Collector,
+		thresholds: opts.Thresholds,
+		chats:      chats,
+		deliver:    opts.Deliver,
+		interval:   opts.Interval,
+		logger:     logger,
+		active:     make(map[string]bool),
+	}
+	if alerts.interval < 10*time.Second {
+		alerts.interval = 10 * time.Second
+	}
+	alerts.thresholds.HysteresisPercent = min(max(alerts.thresholds.HysteresisPercent, 0), 100)
+	return alerts, nil
+}
+
+// Run samples until ctx is done.
+func (m *MetricsAlerts) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, text := range m.evaluate(m.collector.Collect()) {
+			m.logger.Warn("metrics alert", "alert", text)
+			deliverToChats(ctx, m.deliver, m.chats, text, m.logger)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// evaluate compares a reading with the thresholds and returns the alert and
+// recovery messages to send.
+func (m *MetricsAlerts) evaluate(metrics Metrics) []string {
+	var messages []string
+	for _, check := range m.checks(metrics) {
+		if check.threshold <= 0 {
+			continue
+		}
+		clearBelow := check.threshold * (1 - m.thresholds.HysteresisPercent/100)
+		switch {
+		case !m.active[check.key] && check.value >= check.threshold:
+			m.active[check.key] = true
+			messages = append(messages, fmt.Sprintf("High %s: %s (threshold %s).",
+				check.label, formatMetric(check.value, check.unit), formatMetric(check.threshold, check.unit)))
+		case m.active[check.key] && check.value < clearBelow:
+			m.active[check.key] = false
+			messages = append(messages, fmt.Sprintf("%s back to normal: %s.",
+				upperFirst(check.label), formatMetric(check.value, check.unit)))
+		}
+	}
+
+	if m.thresholds.Throttling && metrics.Throttling != nil {
+		switch throttled := metrics.Throttling.Active(); {
+		case throttled && !m.active["throttling"]:
+			m.active["throttling"] = true
+			messages = append(messages, "Pi is throttling: "+strings.Join(metrics.Throttling.Current(), ", ")+".")
+		case !throttled && m.active["throttling"]:
+			m.active["throttling"] = false
+			messages = append(messages, "Pi throttling cleared.")
+		}
+	}
+	return messages
+}
+
+func (m *MetricsAlerts) checks(metrics Metrics) []metricCheck {
+	checks := make([]metricCheck, 0, 3+len(metrics.Disks))
+	if metrics.CPUs > 0 && metrics.Load1 > 0 {
+		checks = append(checks, metricCheck{
+			key:       "load",
+			label:     "load",
+			value:     metrics.Load1 / float64(metrics.CPUs),
+			threshold: m.thresholds.LoadPerCPU,
+			unit:      " per CPU",
+		})
+	}
+	if temp, ok := metrics.MaxTemperature(); ok {
+		checks = append(checks, metricCheck{
+			key:       "temperature",
+			label:     "temperature",
+			value:     temp.Celsius,
+			threshold: m.thresholds.TemperatureC,
+			unit:      " C",
+		})
+	}
+	if metrics.MemTotalMB > 0 {
+		checks = append(checks, metricCheck{
+			key:       "memory",
+			label:     "memory use",
+			value:     metrics.MemUsedPercent,
+			threshold: m.thresholds.MemoryPercent,
+			unit:      "%",
+		})
+	}
+	for _, disk := range metrics.Disks {
+		checks = append(checks, metricCheck{
+			key:       "disk:" + disk.Mount,
+			label:     "disk use on " + disk.Mount,
+			value:     disk.UsedPercent,
+			threshold: m.thresholds.DiskPercent,
+			unit:      "%",
+		})
+	}
+	return checks
+}
+
+func formatMetric(value float64, unit string) string {
+	if unit == " per CPU" {
+		return fmt.Sprintf("%.2f%s", value, unit)
+	}
+	return fmt.Sprintf("%.1f%s", value, unit)
+}
+
+func upperFirst(value string) string {
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
@@ -0,0 +1,37 @@
+package monitor
+
+import (
+	"context"
+
+	"clawkangsar/internal/core"
+)
+
+// NewMetricsTool exposes the collector as the system_metrics tool.
+func NewMetricsTool(collector *MetricsCollector) core.Tool {
+	return &metricsTool{collector: collector}
+}
+
+type metricsTool struct {
+	collector *MetricsCollector
+}
+
+func (t *metricsTool) Name() string { return "system_metrics" }
+
+func (t *metricsTool) Available() bool { return t.collector != nil }
+
+func (t *metricsTool) RequiredRole() core.Role { return core.RoleViewer }
+
+func (t *metricsTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Read current host metrics: CPU load, temperatures, memory, swap, disk usage per mount and Raspberry Pi throttling flags.",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+	}
+}
+
+func (t *metricsTool) Execute(_ context.Context, _ map[string]any) (string, error) {
+	return t.collector.Collect().Format(), nil
+}
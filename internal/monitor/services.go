@@ -0,0 +1,337 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"clawkangsar/internal/core"
+	"clawkangsar/internal/tools"
+)
+
+const (
+	kindService   = "service"
+	kindContainer = "container"
+)
+
+// transientStates are passed through on the way to a settled state, so they
+// neither count as a transition nor reset the last settled state.
+var transientStates = map[string]struct{}{
+	"activating":   {},
+	"deactivating": {},
+	"reloading":    {},
+	"restarting":   {},
+	"removing":     {},
+	"starting":     {},
+}
+
+type ServiceWatcherOptions struct {
+	Control    *tools.ServerControl
+	Services   []string
+	Containers []string
+	// Chats receive the alerts, each as "<channel>:<chat id>".
+	Chats    []string
+	Deliver  core.DeliverFunc
+	Interval time.Duration
+	LogLines int
+	// A restart loop is RestartLoopCount restarts within RestartLoopWindow.
+	RestartLoopCount  int
+	RestartLoopWindow time.Duration
+	Logger            *slog.Logger
+}
+
+// WatchStatus is the last observed state of one watched service or container.
+type WatchStatus struct {
+	Kind      string          `json:"kind"`
+	Name      string          `json:"name"`
+	State     tools.UnitState `json:"state"`
+	Since     time.Time       `json:"since"`
+	Checked   time.Time       `json:"checked"`
+	LastError string          `json:"last_error,omitempty"`
+}
+
+// ServiceWatcher polls systemd services and Docker containers and alerts the
+// configured chats when one changes state or keeps restarting.
+type ServiceWatcher struct {
+	mu         sync.Mutex
+	control    *tools.ServerControl
+	chats      []Chat
+	deliver    core.DeliverFunc
+	interval   time.Duration
+	logLines   int
+	loopCount  int
+	loopWindow time.Duration
+	logger     *slog.Logger
+	targets    []*watchTarget
+}
+
+type watchTarget struct {
+	WatchStatus
+	settled   string
+	restarts  []time.Time
+	loopAlert time.Time
+	seen      bool
+}
+
+func NewServiceWatcher(opts ServiceWatcherOptions) (*ServiceWatcher, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if opts.Control == nil {
+		return nil, fmt.Errorf("service watcher needs server control")
+	}
+	chats, err := ParseChats(opts.Chats)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := &ServiceWatcher{
+		control:    opts.Control,
+		chats:      chats,
+		deliver:    opts.Deliver,
+		interval:   opts.Interval,
+		logLines:   opts.LogLines,
+		loopCount:  opts.RestartLoopCount,
+		loopWindow: opts.RestartLoopWindow,
+		logger:     logger,
+	}
+	if watcher.interval < 10*time.Second {
+		watcher.interval = 10 * time.Second
+	}
+	if watcher.loopWindow <= 0 {
+		watcher.loopWindow = 10 * time.Minute
+	}
+
+	for _, service := range uniqueNames(opts.Services) {
+		watcher.targets = append(watcher.targets, &watchTarget{WatchStatus: WatchStatus{Kind: kindService, Name: service}})
+	}
+	for _, container := range uniqueNames(opts.Containers) {
+		watcher.targets = append(watcher.targets, &watchTarget{WatchStatus: WatchStatus{Kind: kindContainer, Name: container}})
+	}
+	return watcher, nil
+}
+
+// Empty reports whether there is nothing to watch.
+func (w *ServiceWatcher) Empty() bool {
+	return len(w.targets) == 0
+}
+
+// Run polls until ctx is done. The first poll only records a baseline.
+func (w *ServiceWatcher) Run(ctx context.Context) {
+	if w.Empty() {
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.poll(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Snapshot returns the watched targets, services first, for /status.
+func (w *ServiceWatcher) Snapshot() []WatchStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	out := make([]WatchStatus, 0, len(w.targets))
+	for _, target := range w.targets {
+		out = append(out, target.WatchStatus)
+	}
+	return out
+}
+
+func (w *ServiceWatcher) poll(ctx context.Context, now time.Time) {
+	for _, target := range w.targets {
+		if ctx.Err() != nil {
+			return
+		}
+
+		state, err := w.probe(ctx, target)
+		w.mu.Lock()
+		target.Checked = now
+		if err != nil {
+			if target.LastError != err.Error() {
+				w.logger.Warn("watch probe failed", "kind", target.Kind, "name", target.Name, "error", err)
+			}
+			target.LastError = err.Error()
+			w.mu.Unlock()
+			continue
+		}
+		target.LastError = ""
+		alerts := w.observe(target, state, now)
+		w.mu.Unlock()
+
+		for _, alert := range alerts {
+			w.alert(ctx, target, alert)
+		}
+	}
+}
+
+func (w *ServiceWatcher) probe(ctx context.Context, target *watchTarget) (tools.UnitState, error) {
+	if target.Kind == kindContainer {
+		return w.control.DockerState(ctx, target.Name)
+	}
+	return w.control.SystemctlState(ctx, target.Name)
+}
+
+// observe records a new state and returns the alert headlines it causes.
+// Callers hold w.mu.
+func (w *ServiceWatcher) observe(target *watchTarget, state tools.UnitState, now time.Time) []string {
+	previous := target.State
+	target.State = state
+	if !target.seen {
+		target.seen = true
+		target.Since = now
+		if !isTransient(state) {
+			target.settled = settledState(state)
+		}
+		return nil
+	}
+
+	var alerts []string
+	if added := state.Restarts - previous.Restarts; added > 0 {
+		for range min(added, max(w.loopCount, 1)) {
+			target.restarts = append(target.restarts, now)
+		}
+	}
+	cutoff := now.Add(-w.loopWindow)
+	drop := 0
+	for drop < len(target.restarts) && !target.restarts[drop].After(cutoff) {
+		drop++
+	}
+	target.restarts = target.restarts[drop:]
+	if w.loopCount > 0 && len(target.restarts) >= w.loopCount && now.Sub(target.loopAlert) >= w.loopWindow {
+		target.loopAlert = now
+		alerts = append(alerts, fmt.Sprintf("%s is restart looping: %d restarts in %s, now %s.",
+			target.label(), len(target.restarts), formatWindow(w.loopWindow), describeState(state)))
+	}
+
+	if isTransient(state) {
+		return alerts
+	}
+	current := settledState(state)
+	if target.settled != "" && current != target.settled {
+		verb := "changed"
+		if isHealthy(state) {
+			verb = "recovered"
+		}
+		alerts = append(alerts, fmt.Sprintf("%s %s: %s -> %s.", target.label(), verb, target.settled, describeState(state)))
+	}
+	if current != target.settled {
+		target.settled = current
+		target.Since = now
+	}
+	return alerts
+}
+
+func (w *ServiceWatcher) alert(ctx context.Context, target *watchTarget, headline string) {
+	w.logger.Warn("watch alert", "kind", target.Kind, "name", target.Name, "alert", headline)
+
+	text := headline
+	if logs := w.recentLogs(ctx, target); logs != "" {
+		text += "\n\nLast log lines:\n" + tailText(logs, maxAlertLogChars)
+	}
+	deliverToChats(ctx, w.deliver, w.chats, text, w.logger)
+}
+
+func (w *ServiceWatcher) recentLogs(ctx context.Context, target *watchTarget) string {
+	if w.logLines <= 0 {
+		return ""
+	}
+
+	var (
+		logs string
+		err  error
+	)
+	if target.Kind == kindContainer {
+		logs, err = w.control.DockerLogs(ctx, target.Name, w.logLines)
+	} else {
+		logs, err = w.control.JournalTail(ctx, target.Name, w.logLines)
+	}
+	if err != nil {
+		w.logger.Debug("watch logs unavailable", "kind", target.Kind, "name", target.Name, "error", err)
+		return ""
+	}
+	return logs
+}
+
+func (t *watchTarget) label() string {
+	if t.Kind == kindContainer {
+		return "Container " + t.Name
+	}
+	return "Service " + t.Name
+}
+
+func isTransient(state tools.UnitState) bool {
+	_, stateTransient := transientStates[state.State]
+	_, detailTransient := transientStates[state.Detail]
+	return stateTransient || (state.State == "running" && detailTransient)
+}
+
+func isHealthy(state tools.UnitState) bool {
+	switch state.State {
+	case "active", "running":
+		return state.Detail != "unhealthy"
+	}
+	return false
+}
+
+// settledState is the part of a state that is compared between polls. The
+// systemd SubState is left out because it changes during normal operation.
+func settledState(state tools.UnitState) string {
+	if state.State == "running" && state.Detail != "" {
+		return state.State + " (" + state.Detail + ")"
+	}
+	return state.State
+}
+
+func describeState(state tools.UnitState) string {
+	if state.Detail == "" || state.Detail == state.State {
+		return state.State
+	}
+	return state.State + " (" + state.Detail + ")"
+}
+
+// formatWindow spells out whole minutes, which is how the window is
+// configured, and falls back to Go's duration format otherwise.
+func formatWindow(d time.Duration) string {
+	if d%time.Minute != 0 {
+		return d.String()
+	}
+	if minutes := int(d / time.Minute); minutes != 1 {
+		return fmt.Sprintf("%d minutes", minutes)
+	}
+	return "1 minute"
+}
+
+func uniqueNames(names []string) []string {
+	seen := make(map[string]struct{}, len(names))
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		key := strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
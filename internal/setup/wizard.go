@@ -129,6 +129,9 @@ func (w *wizard) configureGateways(cfg *config.Config) error {
 			return err
 		}
 		cfg.Telegram.AllowList = allowList
+		for _, id := range allowList {
+			cfg.Access.Users["telegram:"+strconv.FormatInt(id, 10)] = "admin"
+		}
 	} else {
 		cfg.Telegram.Token = ""
 		cfg.Telegram.AllowList = []int64{}
@@ -145,6 +148,23 @@ func (w *wizard) configureGateways(cfg *config.Config) error {
 			return err
 		}
 		cfg.WhatsApp.SessionDSN = sessionDSN
+
+		allowList, err := w.promptStringList("WhatsApp allow-list phone numbers", cfg.WhatsApp.AllowList)
+		if err != nil {
+			return err
+		}
+		cfg.WhatsApp.AllowList = allowList
+		for _, number := range allowList {
+			cfg.Access.Users["whatsapp:"+number] = "admin"
+		}
+
+		groupPolicy, err := w.promptChoice("WhatsApp group messages", []string{"ignore", "mention", "always"}, 0)
+		if err != nil {
+			return err
+		}
+		cfg.WhatsApp.GroupPolicy = groupPolicy
+	} else {
+		cfg.WhatsApp.AllowList = []string{}
 	}
 
 	fmt.Fprintln(w.stdout)
@@ -154,10 +174,16 @@ func (w *wizard) configureGateways(cfg *config.Config) error {
 func (w *wizard) configureLLM(cfg *config.Config) error {
 	fmt.Fprintln(w.stdout, "LLM setup")
 
-	options := []string{"disabled", "codex_oauth", "openai_compat"}
+	options := []string{"disabled", "codex_oauth", "openai_compat", "anthropic", "ollama", "llamacpp"}
 	defaultIndex := 1
 	if cfg.LLM.Enabled {
 		switch cfg.LLM.Provider {
+		case "llamacpp":
+			defaultIndex = 5
+		case "ollama":
+			defaultIndex = 4
+		case "anthropic":
+			defaultIndex = 3
 		case "openai_compat":
 			defaultIndex = 2
 		case "codex_oauth":
@@ -218,31 +244,90 @@ func (w *wizard) configureLLM(cfg *config.Config) error {
 		}
 		cfg.LLM.Model = model
 
-		useEnv, err := w.promptYesNo("Use environment variable for API key", true)
+		if err := w.promptAPIKey(cfg, "OPENAI_API_KEY"); err != nil {
+			return err
+		}
+		cfg.LLM.CodexAuthPath = ""
+	case "anthropic":
+		cfg.LLM.Enabled = true
+		cfg.LLM.Provider = "anthropic"
+		cfg.LLM.AuthMethod = "api_key"
+		cfg.LLM.BaseURL = "https://api.anthropic.com"
+
+		model, err := w.promptRequired("Anthropic model name", cfg.LLM.Model)
 		if err != nil {
 			return err
 		}
-		if useEnv {
-			envName, err := w.promptLine("API key environment variable", fallbackString(cfg.LLM.APIKeyEnv, "OPENAI_API_KEY"))
-			if err != nil {
-				return err
-			}
-			cfg.LLM.APIKeyEnv = envName
-			cfg.LLM.APIKey = ""
-		} else {
-			key, err := w.promptRequired("API key", cfg.LLM.APIKey)
-			if err != nil {
-				return err
-			}
-			cfg.LLM.APIKey = key
+		cfg.LLM.Model = model
+
+		apiKeyEnv := cfg.LLM.APIKeyEnv
+		if apiKeyEnv == "" || apiKeyEnv == "OPENAI_API_KEY" {
+			apiKeyEnv = "ANTHROPIC_API_KEY"
+		}
+		cfg.LLM.APIKeyEnv = apiKeyEnv
+		if err := w.promptAPIKey(cfg, apiKeyEnv); err != nil {
+			return err
 		}
 		cfg.LLM.CodexAuthPath = ""
+	case "ollama", "llamacpp":
+		defaultURL := "http://127.0.0.1:11434"
+		if provider == "llamacpp" {
+			defaultURL = "http://127.0.0.1:8080"
+		}
+		if cfg.LLM.Provider == provider {
+			defaultURL = fallbackString(cfg.LLM.BaseURL, defaultURL)
+		}
+
+		cfg.LLM.Enabled = true
+		cfg.LLM.Provider = provider
+		cfg.LLM.AuthMethod = "none"
+		cfg.LLM.APIKey = ""
+		cfg.LLM.CodexAuthPath = ""
+
+		baseURL, err := w.promptLine("Local server URL", defaultURL)
+		if err != nil {
+			return err
+		}
+		cfg.LLM.BaseURL = baseURL
+
+		prompt := w.promptRequired
+		if provider == "llamacpp" {
+			prompt = w.promptLine
+		}
+		model, err := prompt("Model name", cfg.LLM.Model)
+		if err != nil {
+			return err
+		}
+		cfg.LLM.Model = model
 	}
 
 	fmt.Fprintln(w.stdout)
 	return nil
 }
 
+func (w *wizard) promptAPIKey(cfg *config.Config, defaultEnv string) error {
+	useEnv, err := w.promptYesNo("Use environment variable for API key", true)
+	if err != nil {
+		return err
+	}
+	if useEnv {
+		envName, err := w.promptLine("API key environment variable", fallbackString(cfg.LLM.APIKeyEnv, defaultEnv))
+		if err != nil {
+			return err
+		}
+		cfg.LLM.APIKeyEnv = envName
+		cfg.LLM.APIKey = ""
+		return nil
+	}
+
+	key, err := w.promptRequired("API key", cfg.LLM.APIKey)
+	if err != nil {
+		return err
+	}
+	cfg.LLM.APIKey = key
+	return nil
+}
+
 func (w *wizard) configureTools(cfg *config.Config) error {
 	fmt.Fprintln(w.stdout, "Tool setup")
 
@@ -300,6 +385,61 @@ func (w *wizard) configureTools(cfg *config.Config) error {
 		cfg.Tools.JournalAllowUnits = []string{}
 	}
 
+	if systemctlEnabled || dockerEnabled {
+		watchEnabled, err := w.promptYesNo("Alert chats when allow-listed services or containers change state", cfg.Watch.Enabled)
+		if err != nil {
+			return err
+		}
+		cfg.Watch.Enabled = watchEnabled
+		if watchEnabled {
+			chats, err := w.promptStringList("Alert chats as <channel>:<chat id>", cfg.Watch.Chats)
+			if err != nil {
+				return err
+			}
+			cfg.Watch.Chats = chats
+		}
+	} else {
+		cfg.Watch.Enabled = false
+	}
+
+	if journalEnabled || dockerEnabled {
+		logWatch, err := w.promptYesNo("Alert chats on log lines matching OOM, segfault and other log_watch rules", cfg.LogWatch.Enabled)
+		if err != nil {
+			return err
+		}
+		cfg.LogWatch.Enabled = logWatch
+		if logWatch {
+			defaults := cfg.LogWatch.Chats
+			if len(defaults) == 0 {
+				defaults = cfg.Watch.Chats
+			}
+			chats, err := w.promptStringList("Log alert chats as <channel>:<chat id>", defaults)
+			if err != nil {
+				return err
+			}
+			cfg.LogWatch.Chats = chats
+		}
+	} else {
+		cfg.LogWatch.Enabled = false
+	}
+
+	metricsAlerts, err := w.promptYesNo("Alert chats on high load, temperature, memory or disk use", cfg.SystemMetrics.AlertsEnabled)
+	if err != nil {
+		return err
+	}
+	cfg.SystemMetrics.AlertsEnabled = metricsAlerts
+	if metricsAlerts {
+		defaults := cfg.SystemMetrics.Chats
+		if len(defaults) == 0 {
+			defaults = cfg.Watch.Chats
+		}
+		chats, err := w.promptStringList("Metric alert chats as <channel>:<chat id>", defaults)
+		if err != nil {
+			return err
+		}
+		cfg.SystemMetrics.Chats = chats
+	}
+
 	fmt.Fprintln(w.stdout)
 	return nil
 }
@@ -329,6 +469,38 @@ func (w *wizard) configureHealth(cfg *config.Config) error {
 	}
 	cfg.Health.Port = port
 
+	apiEnabled, err := w.promptYesNo("Enable admin API under /api", cfg.API.Enabled)
+	if err != nil {
+		return err
+	}
+	cfg.API.Enabled = apiEnabled
+	if apiEnabled {
+		tokenEnv, err := w.promptLine("Admin API token environment variable", cfg.API.TokenEnv)
+		if err != nil {
+			return err
+		}
+		cfg.API.TokenEnv = tokenEnv
+	}
+
+	dashboardEnabled, err := w.promptYesNo("Enable web dashboard at /dashboard/", cfg.Dashboard.Enabled)
+	if err != nil {
+		return err
+	}
+	cfg.Dashboard.Enabled = dashboardEnabled
+	if dashboardEnabled {
+		username, err := w.promptLine("Dashboard username", cfg.Dashboard.Username)
+		if err != nil {
+			return err
+		}
+		cfg.Dashboard.Username = username
+
+		passwordEnv, err := w.promptLine("Dashboard password environment variable", cfg.Dashboard.PasswordEnv)
+		if err != nil {
+			return err
+		}
+		cfg.Dashboard.PasswordEnv = passwordEnv
+	}
+
 	fmt.Fprintln(w.stdout)
 	return nil
 }
@@ -392,6 +564,12 @@ func profileConfig(name string) (config.Config, error) {
 			"mosquitto.service",
 			"node-red.service",
 		}
+		cfg.LogWatch.Rules = append(cfg.LogWatch.Rules, config.LogRuleConfig{
+			Name:            "zigbee_disconnect",
+			Pattern:         "(?i)adapter disconnected|coordinator.*(lost|failed)|failed to ping|zigbee.*(disconnect|offline)",
+			Sources:         []string{"zigbee2mqtt"},
+			CooldownSeconds: 600,
+		})
 	default:
 		return config.Config{}, fmt.Errorf("unknown profile %q; available: %s", name, strings.Join(AvailableProfiles(), ", "))
 	}
@@ -450,6 +628,24 @@ func printWarnings(out io.Writer, cfg config.Config) {
 	if cfg.LLM.Enabled && cfg.LLM.Provider == "openai_compat" && strings.TrimSpace(cfg.LLM.APIKey) == "" && strings.TrimSpace(cfg.LLM.APIKeyEnv) == "" {
 		warnings = append(warnings, "OpenAI-compatible LLM is enabled but no API key or environment variable is configured.")
 	}
+	if cfg.Watch.Enabled && len(cfg.Watch.Chats) == 0 {
+		warnings = append(warnings, "Service watch is enabled but chats is empty.")
+	}
+	if cfg.LogWatch.Enabled && len(cfg.LogWatch.Chats) == 0 {
+		warnings = append(warnings, "Log watch is enabled but chats is empty.")
+	}
+	if cfg.SystemMetrics.AlertsEnabled && len(cfg.SystemMetrics.Chats) == 0 {
+		warnings = append(warnings, "System metric alerts are enabled but chats is empty.")
+	}
+	if cfg.API.Enabled && strings.TrimSpace(cfg.API.Token) == "" && strings.TrimSpace(cfg.API.TokenEnv) == "" && cfg.Health.ClientCAFile == "" {
+		warnings = append(warnings, "Admin API is enabled but no token, token environment variable or client CA is configured.")
+	}
+	if cfg.API.Enabled && cfg.Health.TLSCertFile == "" {
+		warnings = append(warnings, "Admin API is enabled without TLS; only expose it on a trusted network.")
+	}
+	if cfg.Dashboard.Enabled && strings.TrimSpace(cfg.Dashboard.Password) == "" && strings.TrimSpace(cfg.Dashboard.PasswordEnv) == "" {
+		warnings = append(warnings, "Web dashboard is enabled but no password or password environment variable is configured.")
+	}
 
 	if len(warnings) == 0 {
 		fmt.Fprintln(out, "Config looks complete enough to start.")
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+
+	"clawkangsar/internal/core"
+)
+
+// MigrateJSONSessions imports session files from dir into the SQLite backend.
+// Sessions already present in SQLite are left alone. Every readable session
+// file is renamed to *.json.migrated so the import runs only once.
+func MigrateJSONSessions(dir string, target *SQLiteSessionBackend) (int, error) {
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	source, err := core.NewJSONSessionBackend(dir)
+	if err != nil {
+		return 0, err
+	}
+	sessions, err := source.LoadSessions()
+	if err != nil {
+		return 0, fmt.Errorf("read json sessions: %w", err)
+	}
+
+	migrated := 0
+	for _, session := range sessions {
+		exists, err := target.HasSession(session.Key)
+		if err != nil {
+			return migrated, fmt.Errorf("check session %s: %w", session.Key, err)
+		}
+		if !exists {
+			if err := target.SaveSession(session); err != nil {
+				return migrated, fmt.Errorf("import session %s: %w", session.Key, err)
+			}
+			migrated++
+		}
+
+		path, err := source.Path(session.Key)
+		if err != nil {
+			continue
+		}
+		if err := os.Rename(path, path+".migrated"); err != nil && !os.IsNotExist(err) {
+			return migrated, fmt.Errorf("mark migrated session file: %w", err)
+		}
+	}
+
+	return migrated, nil
+}
@@ -0,0 +1,218 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"clawkangsar/internal/core"
+)
+
+const sessionSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	key        TEXT PRIMARY KEY,
+	created    TIMESTAMP NOT NULL,
+	updated    TIMESTAMP NOT NULL,
+	summary    TEXT NOT NULL DEFAULT '',
+	summarized INTEGER NOT NULL DEFAULT 0
+);
+CREATE TABLE IF NOT EXISTS session_messages (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	session_key TEXT NOT NULL,
+	channel     TEXT NOT NULL,
+	user_id     TEXT NOT NULL,
+	chat_id     TEXT NOT NULL,
+	text        TEXT NOT NULL,
+	timestamp   TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_session_messages_session ON session_messages (session_key, id);
+`
+
+// SQLiteSessionBackend stores sessions as append-only message rows keyed by
+// session, so adding a message costs one insert instead of a file rewrite.
+type SQLiteSessionBackend struct {
+	db *sql.DB
+}
+
+func OpenSQLiteSessions(path string) (*SQLiteSessionBackend, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("session database path is required")
+	}
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create session database directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_journal_mode=WAL&_busy_timeout=5000&_synchronous=NORMAL")
+	if err != nil {
+		return nil, fmt.Errorf("open session database: %w", err)
+	}
+	// SQLite allows one writer; a single connection avoids lock contention.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sessionSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("create session schema: %w", err)
+	}
+
+	return &SQLiteSessionBackend{db: db}, nil
+}
+
+func (b *SQLiteSessionBackend) LoadSessions() ([]core.Session, error) {
+	rows, err := b.db.Query(`SELECT key, created, updated, summary, summarized FROM sessions ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("load sessions: %w", err)
+	}
+
+	sessions := make([]core.Session, 0, 16)
+	index := make(map[string]int)
+	for rows.Next() {
+		var session core.Session
+		if err := rows.Scan(&session.Key, &session.Created, &session.Updated, &session.Summary, &session.Summarized); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan session: %w", err)
+		}
+		index[session.Key] = len(sessions)
+		sessions = append(sessions, session)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load sessions: %w", err)
+	}
+
+	rows, err = b.db.Query(`SELECT session_key, channel, user_id, chat_id, text, timestamp FROM session_messages ORDER BY session_key, id`)
+	if err != nil {
+		return nil, fmt.Errorf("load session messages: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var msg core.Message
+		if err := rows.Scan(&key, &msg.Channel, &msg.UserID, &msg.ChatID, &msg.Text, &msg.Timestamp); err != nil {
+			return nil, fmt.Errorf("scan session message: %w", err)
+		}
+		i, ok := index[key]
+		if !ok {
+			continue
+		}
+		sessions[i].Messages = append(sessions[i].Messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load session messages: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (b *SQLiteSessionBackend) AppendMessage(session core.Session, msg core.Message) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := upsertSession(tx, session); err != nil {
+		return err
+	}
+	if err := insertMessage(tx, session.Key, msg); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveSession replaces the session and all of its message rows. The store
+// only calls it after pruning or removing messages.
+func (b *SQLiteSessionBackend) SaveSession(session core.Session) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := upsertSession(tx, session); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM session_messages WHERE session_key = ?`, session.Key); err != nil {
+		return fmt.Errorf("clear session messages: %w", err)
+	}
+	for _, msg := range session.Messages {
+		if err := insertMessage(tx, session.Key, msg); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateSessionMeta stores the summary and timestamps and leaves the message
+// rows alone.
+func (b *SQLiteSessionBackend) UpdateSessionMeta(session core.Session) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := upsertSession(tx, session); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (b *SQLiteSessionBackend) DeleteSession(key string) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM session_messages WHERE session_key = ?`, key); err != nil {
+		return fmt.Errorf("delete session messages: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (b *SQLiteSessionBackend) HasSession(key string) (bool, error) {
+	var count int
+	if err := b.db.QueryRow(`SELECT COUNT(*) FROM sessions WHERE key = ?`, key).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (b *SQLiteSessionBackend) Close() error {
+	return b.db.Close()
+}
+
+func upsertSession(tx *sql.Tx, session core.Session) error {
+	_, err := tx.Exec(`
+		INSERT INTO sessions (key, created, updated, summary, summarized) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET updated = excluded.updated, summary = excluded.summary, summarized = excluded.summarized`,
+		session.Key, session.Created, session.Updated, session.Summary, session.Summarized,
+	)
+	if err != nil {
+		return fmt.Errorf("save session %s: %w", session.Key, err)
+	}
+	return nil
+}
+
+func insertMessage(tx *sql.Tx, key string, msg core.Message) error {
+	_, err := tx.Exec(
+		`INSERT INTO session_messages (session_key, channel, user_id, chat_id, text, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
+		key, msg.Channel, msg.UserID, msg.ChatID, msg.Text, msg.Timestamp,
+	)
+	if err != nil {
+		return fmt.Errorf("append session message %s: %w", key, err)
+	}
+	return nil
+}
@@ -29,6 +29,7 @@ type Browser struct {
 	browserCancel context.CancelFunc
 	browserCtx   context.Context
 	lastUsed     time.Time
+	launches     int
 
 	watchdogStop chan struct{}
 	watchdogDone chan struct{}
@@ -38,6 +39,7 @@ type BrowserStats struct {
 	Active             bool      `json:"active"`
 	LastUsed           time.Time `json:"last_used,omitempty"`
 	IdleTimeoutSeconds int       `json:"idle_timeout_seconds"`
+	Launches           int       `json:"launches"`
 }
 
 func NewBrowser(logger *slog.Logger, idleTimeout time.Duration) *Browser {
@@ -65,6 +67,7 @@ func (b *Browser) Stats() BrowserStats {
 	stats := BrowserStats{
 		Active:             b.browserCtx != nil,
 		IdleTimeoutSeconds: int(b.idleTimeout.Seconds()),
+		Launches:           b.launches,
 	}
 	if !b.lastUsed.IsZero() {
 		stats.LastUsed = b.lastUsed
@@ -120,6 +123,17 @@ func (b *Browser) KillIfIdle() bool {
 	return true
 }
 
+// Kill stops the browser now, for example when a page hangs it. It reports
+// whether one was running; the next browse starts a new one.
+func (b *Browser) Kill(reason string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	running := b.browserCtx != nil
+	b.killLocked(reason)
+	return running
+}
+
 func (b *Browser) Close() error {
 	select {
 	case <-b.watchdogDone:
@@ -162,6 +176,7 @@ func (b *Browser) acquireBrowser() (context.Context, error) {
 	b.browserCancel = browserCancel
 	b.browserCtx = browserCtx
 	b.lastUsed = time.Now()
+	b.launches++
 	b.logger.Info("browser started")
 
 	return b.browserCtx, nil
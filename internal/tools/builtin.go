@@ -0,0 +1,490 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"clawkangsar/internal/core"
+)
+
+// RegisterBuiltins adds the web and server-control tools plus their slash
+// commands to the registry. Nil dependencies are skipped.
+func RegisterBuiltins(registry *core.ToolRegistry, browser *Browser, fetcher *WebFetcher, server *ServerControl) error {
+	builtins := make([]core.Tool, 0, 8)
+	if fetcher != nil {
+		builtins = append(builtins, &webFetchTool{fetcher: fetcher})
+	}
+	if browser != nil {
+		builtins = append(builtins, &browserTool{browser: browser, fetcher: fetcher})
+	}
+	if server != nil {
+		builtins = append(builtins,
+			&shellCommandTool{server: server},
+			&systemctlStatusTool{server: server},
+			&systemctlActionTool{server: server},
+			&dockerPSTool{server: server},
+			&dockerLogsTool{server: server},
+			&journalTailTool{server: server},
+		)
+	}
+
+	for _, tool := range builtins {
+		if err := registry.Register(tool); err != nil {
+			return err
+		}
+	}
+
+	commands := make([]core.Command, 0, 6)
+	if fetcher != nil {
+		commands = append(commands, core.Command{
+			Name:    "/fetch",
+			Usage:   "Provide a URL after /fetch.",
+			Role:    core.RoleViewer,
+			Resolve: resolveURLCommand("web_fetch", false),
+		})
+	}
+	if browser != nil {
+		commands = append(commands, core.Command{
+			Name:    "/browse",
+			Usage:   "Provide a URL after /browse.",
+			Role:    core.RoleViewer,
+			Resolve: resolveURLCommand("browser_browse", fetcher != nil),
+		})
+	}
+	if server != nil {
+		commands = append(commands,
+			core.Command{
+				Name:    "/cmd",
+				Usage:   "Usage: /cmd <name>.",
+				Role:    core.RoleOperator,
+				Resolve: resolveNamedCommand,
+			},
+			core.Command{
+				Name:    "/service",
+				Usage:   "Usage: /service status <name> or /service <start|stop|restart> <name>.",
+				Role:    core.RoleViewer,
+				Resolve: resolveServiceCommand,
+			},
+			core.Command{
+				Name:    "/docker",
+				Usage:   "Usage: /docker ps or /docker logs <container> [lines].",
+				Role:    core.RoleViewer,
+				Resolve: resolveDockerCommand,
+			},
+			core.Command{
+				Name:    "/logs",
+				Usage:   "Usage: /logs <unit> [lines].",
+				Role:    core.RoleViewer,
+				Resolve: resolveLogsCommand,
+			},
+		)
+	}
+
+	for _, cmd := range commands {
+		if err := registry.RegisterCommand(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveURLCommand(tool string, fetchFirst bool) func(args []string) (core.ToolCall, bool) {
+	return func(args []string) (core.ToolCall, bool) {
+		if len(args) == 0 {
+			return core.ToolCall{}, false
+		}
+		call := core.ToolCall{
+			Name:      tool,
+			Arguments: map[string]any{"url": args[0]},
+		}
+		if fetchFirst {
+			call.Arguments["fetch_first"] = true
+		}
+		return call, true
+	}
+}
+
+func resolveNamedCommand(args []string) (core.ToolCall, bool) {
+	if len(args) == 0 {
+		return core.ToolCall{}, false
+	}
+	return core.ToolCall{
+		Name:      "shell_command",
+		Arguments: map[string]any{"name": strings.Join(args, " ")},
+	}, true
+}
+
+func resolveServiceCommand(args []string) (core.ToolCall, bool) {
+	if len(args) < 2 {
+		return core.ToolCall{}, false
+	}
+
+	action := strings.ToLower(strings.TrimSpace(args[0]))
+	switch action {
+	case "status":
+		return core.ToolCall{
+			Name:      "systemctl_status",
+			Arguments: map[string]any{"service": args[1]},
+		}, true
+	case "start", "stop", "restart":
+		return core.ToolCall{
+			Name:      "systemctl_action",
+			Arguments: map[string]any{"service": args[1], "action": action},
+		}, true
+	default:
+		return core.ToolCall{}, false
+	}
+}
+
+func resolveDockerCommand(args []string) (core.ToolCall, bool) {
+	if len(args) == 0 {
+		return core.ToolCall{}, false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(args[0])) {
+	case "ps":
+		return core.ToolCall{Name: "docker_ps", Arguments: map[string]any{}}, true
+	case "logs":
+		if len(args) < 2 {
+			return core.ToolCall{}, false
+		}
+		call := core.ToolCall{
+			Name:      "docker_logs",
+			Arguments: map[string]any{"container": args[1]},
+		}
+		if len(args) >= 3 {
+			call.Arguments["lines"] = args[2]
+		}
+		return call, true
+	default:
+		return core.ToolCall{}, false
+	}
+}
+
+func resolveLogsCommand(args []string) (core.ToolCall, bool) {
+	if len(args) == 0 {
+		return core.ToolCall{}, false
+	}
+	call := core.ToolCall{
+		Name:      "journal_tail",
+		Arguments: map[string]any{"unit": args[0]},
+	}
+	if len(args) >= 2 {
+		call.Arguments["lines"] = args[1]
+	}
+	return call, true
+}
+
+func urlParameters(description string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"url": map[string]any{
+				"type":        "string",
+				"description": description,
+			},
+		},
+		"required": []string{"url"},
+	}
+}
+
+func requireString(args map[string]any, key string) (string, error) {
+	value := core.StringArgument(args, key)
+	if value == "" {
+		return "", errors.New("missing required string field `" + key + "`")
+	}
+	return value, nil
+}
+
+type webFetchTool struct {
+	fetcher *WebFetcher
+}
+
+func (t *webFetchTool) Name() string { return "web_fetch" }
+
+func (t *webFetchTool) Available() bool { return t.fetcher != nil }
+
+func (t *webFetchTool) RequiredRole() core.Role { return core.RoleViewer }
+
+func (t *webFetchTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Fetch a URL using a lightweight HTTP request and return readable text content. Prefer this for real-time data.",
+		Parameters:  urlParameters("HTTP or HTTPS URL to fetch"),
+	}
+}
+
+func (t *webFetchTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	target, err := requireString(args, "url")
+	if err != nil {
+		return "", err
+	}
+	return t.fetcher.Fetch(ctx, target)
+}
+
+type browserTool struct {
+	browser *Browser
+	fetcher *WebFetcher
+}
+
+func (t *browserTool) Name() string { return "browser_browse" }
+
+func (t *browserTool) Available() bool { return t.browser != nil }
+
+func (t *browserTool) RequiredRole() core.Role { return core.RoleViewer }
+
+func (t *browserTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Open a URL in the headless browser and return visible page text. Use only when lightweight fetch is insufficient.",
+		Parameters:  urlParameters("HTTP or HTTPS URL to browse"),
+	}
+}
+
+func (t *browserTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	target, err := requireString(args, "url")
+	if err != nil {
+		return "", err
+	}
+
+	if t.fetcher != nil && core.BoolArgument(args, "fetch_first") {
+		// Try lightweight HTTP fetch first to avoid booting Chromium on Pi.
+		text, err := t.fetcher.Fetch(ctx, target)
+		if err == nil && strings.TrimSpace(text) != "" {
+			return text, nil
+		}
+	}
+
+	text, err := t.browser.Browse(ctx, target)
+	if err != nil {
+		return "", fmt.Errorf("browse failed: %w", err)
+	}
+	return text, nil
+}
+
+type shellCommandTool struct {
+	server *ServerControl
+}
+
+func (t *shellCommandTool) Name() string { return "shell_command" }
+
+func (t *shellCommandTool) Available() bool { return t.server.ShellAvailable() }
+
+func (t *shellCommandTool) RequiredRole() core.Role { return core.RoleOperator }
+
+func (t *shellCommandTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Run one pre-approved named shell command alias. Allowed names: " + strings.Join(t.server.ShellCommandNames(), ", "),
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"name": map[string]any{
+					"type":        "string",
+					"description": "Approved command alias to run",
+				},
+			},
+			"required": []string{"name"},
+		},
+	}
+}
+
+func (t *shellCommandTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	name, err := requireString(args, "name")
+	if err != nil {
+		return "", err
+	}
+	return t.server.RunNamedCommand(ctx, name)
+}
+
+type systemctlStatusTool struct {
+	server *ServerControl
+}
+
+func (t *systemctlStatusTool) Name() string { return "systemctl_status" }
+
+func (t *systemctlStatusTool) Available() bool { return t.server.SystemctlAvailable() }
+
+func (t *systemctlStatusTool) RequiredRole() core.Role { return core.RoleViewer }
+
+func (t *systemctlStatusTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Get concise systemd service status for one allow-listed service. Allowed services: " + strings.Join(t.server.AllowedServices(), ", "),
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"service": map[string]any{
+					"type":        "string",
+					"description": "Allow-listed systemd service name",
+				},
+			},
+			"required": []string{"service"},
+		},
+	}
+}
+
+func (t *systemctlStatusTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	service, err := requireString(args, "service")
+	if err != nil {
+		return "", err
+	}
+	return t.server.SystemctlStatus(ctx, service)
+}
+
+type systemctlActionTool struct {
+	server *ServerControl
+}
+
+func (t *systemctlActionTool) Name() string { return "systemctl_action" }
+
+func (t *systemctlActionTool) Available() bool { return t.server.SystemctlAvailable() }
+
+func (t *systemctlActionTool) RequiredRole() core.Role { return core.RoleAdmin }
+
+func (t *systemctlActionTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Run start, stop, or restart on one allow-listed service, then return the updated status.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"service": map[string]any{
+					"type":        "string",
+					"description": "Allow-listed systemd service name",
+				},
+				"action": map[string]any{
+					"type":        "string",
+					"description": "One of: start, stop, restart",
+				},
+			},
+			"required": []string{"service", "action"},
+		},
+	}
+}
+
+func (t *systemctlActionTool) ConfirmationRequired(args map[string]any) (string, bool) {
+	service := core.StringArgument(args, "service")
+	action := strings.ToLower(core.StringArgument(args, "action"))
+	if !t.server.SystemctlNeedsConfirmation(action, service) {
+		return "", false
+	}
+	return fmt.Sprintf("%s %s", action, service), true
+}
+
+func (t *systemctlActionTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	service, err := requireString(args, "service")
+	if err != nil {
+		return "", err
+	}
+	action, err := requireString(args, "action")
+	if err != nil {
+		return "", err
+	}
+	return t.server.SystemctlAction(ctx, action, service)
+}
+
+type dockerPSTool struct {
+	server *ServerControl
+}
+
+func (t *dockerPSTool) Name() string { return "docker_ps" }
+
+func (t *dockerPSTool) Available() bool { return t.server.DockerAvailable() }
+
+func (t *dockerPSTool) RequiredRole() core.Role { return core.RoleViewer }
+
+func (t *dockerPSTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "List Docker containers and their status if Docker tools are enabled.",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+	}
+}
+
+func (t *dockerPSTool) Execute(ctx context.Context, _ map[string]any) (string, error) {
+	return t.server.DockerPS(ctx)
+}
+
+type dockerLogsTool struct {
+	server *ServerControl
+}
+
+func (t *dockerLogsTool) Name() string { return "docker_logs" }
+
+func (t *dockerLogsTool) Available() bool { return len(t.server.AllowedContainers()) > 0 }
+
+func (t *dockerLogsTool) RequiredRole() core.Role { return core.RoleViewer }
+
+func (t *dockerLogsTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Read recent logs for one allow-listed Docker container. Allowed containers: " + strings.Join(t.server.AllowedContainers(), ", "),
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"container": map[string]any{
+					"type":        "string",
+					"description": "Allow-listed Docker container name",
+				},
+				"lines": map[string]any{
+					"type":        "integer",
+					"description": "Optional number of log lines to return",
+				},
+			},
+			"required": []string{"container"},
+		},
+	}
+}
+
+func (t *dockerLogsTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	container, err := requireString(args, "container")
+	if err != nil {
+		return "", err
+	}
+	return t.server.DockerLogs(ctx, container, core.IntArgument(args, "lines"))
+}
+
+type journalTailTool struct {
+	server *ServerControl
+}
+
+func (t *journalTailTool) Name() string { return "journal_tail" }
+
+func (t *journalTailTool) Available() bool { return t.server.JournalAvailable() }
+
+func (t *journalTailTool) RequiredRole() core.Role { return core.RoleViewer }
+
+func (t *journalTailTool) Definition() core.ToolDefinition {
+	return core.ToolDefinition{
+		Name:        t.Name(),
+		Description: "Read recent journal logs for one allow-listed systemd unit. Allowed units: " + strings.Join(t.server.AllowedUnits(), ", "),
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"unit": map[string]any{
+					"type":        "string",
+					"description": "Allow-listed systemd unit name",
+				},
+				"lines": map[string]any{
+					"type":        "integer",
+					"description": "Optional number of log lines to return",
+				},
+			},
+			"required": []string{"unit"},
+		},
+	}
+}
+
+func (t *journalTailTool) Execute(ctx context.Context, args map[string]any) (string, error) {
+	unit, err := requireString(args, "unit")
+	if err != nil {
+		return "", err
+	}
+	return t.server.JournalTail(ctx, unit, core.IntArgument(args, "lines"))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os/exec"
 	"runtime"
@@ -23,10 +24,23 @@ type ServerControlOptions struct {
 	ShellCommands          map[string]string
 	SystemctlEnabled       bool
 	SystemctlAllowServices []string
-	DockerEnabled          bool
-	DockerAllowContainers  []string
-	JournalEnabled         bool
-	JournalAllowUnits      []string
+	// SystemctlConfirmActions lists actions that need a /confirm step.
+	// SystemctlConfirmServices overrides that list for individual services.
+	SystemctlConfirmActions  []string
+	SystemctlConfirmServices map[string][]string
+	DockerEnabled            bool
+	DockerAllowContainers    []string
+	JournalEnabled           bool
+	JournalAllowUnits        []string
+}
+
+// UnitState is the state of a systemd service or Docker container. State is
+// the systemd ActiveState or the container status; Detail is the SubState,
+// the container health or its exit code.
+type UnitState struct {
+	State    string `json:"state"`
+	Detail   string `json:"detail,omitempty"`
+	Restarts int    `json:"restarts"`
 }
 
 type ServerControl struct {
@@ -41,6 +55,8 @@ type ServerControl struct {
 
 	systemctlEnabled bool
 	allowedServices  map[string]string
+	confirmActions   map[string]struct{}
+	confirmServices  map[string]map[string]struct{}
 
 	dockerEnabled     bool
 	allowedContainers map[string]string
@@ -88,6 +104,16 @@ func NewServerControl(logger *slog.Logger, opts ServerControlOptions) *ServerCon
 		maxLogLines = defaultLogLines
 	}
 
+	allowedServices := normalizeNameSet(opts.SystemctlAllowServices, true)
+	confirmServices := make(map[string]map[string]struct{}, len(opts.SystemctlConfirmServices))
+	for service, actions := range opts.SystemctlConfirmServices {
+		key := normalizeLookup(service)
+		if allowed, ok := allowedServices[key]; ok {
+			key = normalizeLookup(allowed)
+		}
+		confirmServices[key] = normalizeActionSet(actions)
+	}
+
 	return &ServerControl{
 		logger:            logger,
 		timeout:           timeout,
@@ -96,7 +122,9 @@ func NewServerControl(logger *slog.Logger, opts ServerControlOptions) *ServerCon
 		shellEnabled:      opts.ShellEnabled,
 		shellCommands:     normalizeCommandMap(opts.ShellCommands),
 		systemctlEnabled:  opts.SystemctlEnabled,
-		allowedServices:   normalizeNameSet(opts.SystemctlAllowServices, true),
+		allowedServices:   allowedServices,
+		confirmActions:    normalizeActionSet(opts.SystemctlConfirmActions),
+		confirmServices:   confirmServices,
 		dockerEnabled:     opts.DockerEnabled,
 		allowedContainers: normalizeNameSet(opts.DockerAllowContainers, false),
 		journalEnabled:    opts.JournalEnabled,
@@ -163,11 +191,40 @@ func (s *ServerControl) SystemctlStatus(ctx context.Context, service string) (st
 		"systemctl",
 		"show",
 		"--no-pager",
-		"--property=Id,Description,LoadState,ActiveState,SubState,UnitFileState,MainPID,ExecMainStatus,ExecMainStartTimestamp",
+		"--property=Id,Description,LoadState,ActiveState,SubState,UnitFileState,MainPID,ExecMainStatus,ExecMainStartTimestamp,NRestarts",
 		allowed,
 	)
 }
 
+// SystemctlState returns the parsed SystemctlStatus of an allow-listed
+// service.
+func (s *ServerControl) SystemctlState(ctx context.Context, service string) (UnitState, error) {
+	output, err := s.SystemctlStatus(ctx, service)
+	if err != nil {
+		return UnitState{}, err
+	}
+
+	properties := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			properties[key] = strings.TrimSpace(value)
+		}
+	}
+	if properties["ActiveState"] == "" {
+		return UnitState{}, fmt.Errorf("unexpected systemctl output for %q", strings.TrimSpace(service))
+	}
+
+	state := UnitState{
+		State:  properties["ActiveState"],
+		Detail: properties["SubState"],
+	}
+	if properties["LoadState"] == "not-found" {
+		state.State = "not-found"
+	}
+	state.Restarts, _ = strconv.Atoi(properties["NRestarts"])
+	return state, nil
+}
+
 func (s *ServerControl) SystemctlAction(ctx context.Context, action string, service string) (string, error) {
 	if !s.systemctlEnabled {
 		return "", errors.New("systemctl tools are disabled")
@@ -196,6 +253,22 @@ func (s *ServerControl) SystemctlAction(ctx context.Context, action string, serv
 	return status, nil
 }
 
+// SystemctlNeedsConfirmation reports whether the action on the service must be
+// confirmed by the caller first. Calls that would be rejected anyway do not.
+func (s *ServerControl) SystemctlNeedsConfirmation(action string, service string) bool {
+	allowed, err := s.resolveAllowedService(service)
+	if err != nil {
+		return false
+	}
+
+	actions, ok := s.confirmServices[normalizeLookup(allowed)]
+	if !ok {
+		actions = s.confirmActions
+	}
+	_, required := actions[normalizeLookup(action)]
+	return required
+}
+
 func (s *ServerControl) DockerPS(ctx context.Context) (string, error) {
 	if !s.dockerEnabled {
 		return "", errors.New("docker tools are disabled")
@@ -228,6 +301,44 @@ func (s *ServerControl) DockerPS(ctx context.Context) (string, error) {
 	return strings.Join(filtered, "\n"), nil
 }
 
+// DockerState inspects an allow-listed container. A container that does not
+// exist is reported with state "missing" rather than an error.
+func (s *ServerControl) DockerState(ctx context.Context, container string) (UnitState, error) {
+	if !s.dockerEnabled {
+		return UnitState{}, errors.New("docker tools are disabled")
+	}
+
+	allowed, err := s.resolveAllowedContainer(container)
+	if err != nil {
+		return UnitState{}, err
+	}
+
+	output, err := s.run(ctx,
+		"docker",
+		"inspect",
+		"--format",
+		"{{.State.Status}}\t{{if .State.Health}}{{.State.Health.Status}}{{end}}\t{{.State.ExitCode}}\t{{.RestartCount}}",
+		allowed,
+	)
+	if err != nil {
+		if strings.Contains(strings.ToLower(err.Error()), "no such") {
+			return UnitState{State: "missing"}, nil
+		}
+		return UnitState{}, err
+	}
+
+	fields := strings.Split(output, "\t")
+	if len(fields) != 4 {
+		return UnitState{}, fmt.Errorf("unexpected docker inspect output for %q", allowed)
+	}
+	state := UnitState{State: fields[0], Detail: fields[1]}
+	if state.Detail == "" && state.State == "exited" {
+		state.Detail = "exit code " + fields[2]
+	}
+	state.Restarts, _ = strconv.Atoi(fields[3])
+	return state, nil
+}
+
 func (s *ServerControl) DockerLogs(ctx context.Context, container string, lines int) (string, error) {
 	if !s.dockerEnabled {
 		return "", errors.New("docker tools are disabled")
@@ -254,6 +365,70 @@ func (s *ServerControl) JournalTail(ctx context.Context, unit string, lines int)
 	return s.run(ctx, "journalctl", "-u", allowed, "-n", strconv.Itoa(s.clampLines(lines)), "--no-pager")
 }
 
+// LogStream is a running follow command. Read Output until it returns an
+// error; io.EOF means the command exited cleanly. Close stops the command.
+type LogStream struct {
+	Output io.Reader
+	cancel context.CancelFunc
+	reader *io.PipeReader
+}
+
+func (l *LogStream) Close() error {
+	l.cancel()
+	return l.reader.Close()
+}
+
+// JournalFollow streams new journal entries of an allow-listed unit as JSON
+// lines, as written by "journalctl -f -o json".
+func (s *ServerControl) JournalFollow(ctx context.Context, unit string) (*LogStream, error) {
+	if !s.journalEnabled {
+		return nil, errors.New("journalctl tools are disabled")
+	}
+
+	allowed, err := s.resolveAllowedUnit(unit)
+	if err != nil {
+		return nil, err
+	}
+	return s.follow(ctx, "journalctl", "-u", allowed, "-f", "-n", "0", "-o", "json", "--no-pager")
+}
+
+// DockerFollow streams new stdout and stderr lines of an allow-listed
+// container. The stream ends when the container stops.
+func (s *ServerControl) DockerFollow(ctx context.Context, container string) (*LogStream, error) {
+	if !s.dockerEnabled {
+		return nil, errors.New("docker tools are disabled")
+	}
+
+	allowed, err := s.resolveAllowedContainer(container)
+	if err != nil {
+		return nil, err
+	}
+	return s.follow(ctx, "docker", "logs", "-f", "--tail", "0", allowed)
+}
+
+func (s *ServerControl) follow(ctx context.Context, name string, args ...string) (*LogStream, error) {
+	followCtx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+
+	cmd := exec.CommandContext(followCtx, name, args...)
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+
+	s.logger.Info("following logs", "command", name, "args", strings.Join(args, " "))
+	go func() {
+		err := cmd.Wait()
+		if err != nil {
+			err = fmt.Errorf("%s exited: %w", name, err)
+		}
+		_ = writer.CloseWithError(err)
+	}()
+	return &LogStream{Output: reader, cancel: cancel, reader: reader}, nil
+}
+
 func (s *ServerControl) clampLines(lines int) int {
 	if lines <= 0 {
 		return s.defaultLogLines
@@ -356,6 +531,16 @@ func normalizeNameSet(source []string, serviceAliases bool) map[string]string {
 	return normalized
 }
 
+func normalizeActionSet(actions []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(actions))
+	for _, action := range actions {
+		if value := normalizeLookup(action); value != "" {
+			set[value] = struct{}{}
+		}
+	}
+	return set
+}
+
 func normalizeLookup(value string) string {
 	return strings.ToLower(strings.TrimSpace(value))
 }